@@ -0,0 +1,50 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/alert"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
+)
+
+// configureAlertCmd represents the alert command.
+func configureAlertCmd(app *kingpin.Application) {
+	alertCmd := app.Command("alert", "List the current alerts. For more information and additional flags see help").PreAction(requireAlertManagerURL)
+	configureAlertListCmd(alertCmd)
+}
+
+// getAlerts returns the current alerts matching all the matchers, silenced
+// and inhibited ones included.
+func getAlerts(ctx context.Context, amclient *client.AlertmanagerAPI, tenant string, matchers []labels.Matcher) (models.GettableAlerts, error) {
+	filter := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		filter = append(filter, m.String())
+	}
+	params := alert.NewGetAlertsParams().WithContext(ctx).WithTimeout(timeout).WithFilter(filter)
+	var getOk *alert.GetAlertsOK
+	err := withRetry(ctx, "query alerts"+forTenant(tenant), func() (err error) {
+		getOk, err = amclient.Alert.GetAlerts(params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return getOk.Payload, nil
+}
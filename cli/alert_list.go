@@ -0,0 +1,260 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/prometheus/alertmanager/api/v2/client/alert"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/cli/format"
+)
+
+type alertListCmd struct {
+	matchers  []string
+	active    bool
+	silenced  bool
+	inhibited bool
+	fields    string
+	tenantFlags
+}
+
+const alertListHelp = `List the current alerts
+
+  The non-option section of arguments constructs a list of "Matcher Groups"
+  filtering the alerts, with the same syntax as silence add:
+
+  atm alert list alertname=foo node=bar
+
+	This query will match all the alerts with the alertname=foo and
+	node=bar label value pairs set.
+
+  atm alert list --no-silenced --no-inhibited --tenant.file tenants.conf
+
+	This query will match the alerts of all the tenants of the file which
+	are neither silenced nor inhibited, each row being prefixed with the
+	tenant it came from.
+
+  atm alert list --fields fingerprint,labels,silencedBy
+
+	The table output only has the given fields, in their order, instead of
+	the tenant when querying several tenants, labels, state, startsAt and
+	endsAt.
+`
+
+func configureAlertListCmd(cc *kingpin.CmdClause) {
+	var (
+		c       = &alertListCmd{}
+		listCmd = cc.Command("list", alertListHelp).Default()
+	)
+	c.tenantFlags.configure(listCmd)
+	listCmd.Flag("active", "Show the active alerts").Default("true").BoolVar(&c.active)
+	listCmd.Flag("silenced", "Show the silenced alerts").Default("true").BoolVar(&c.silenced)
+	listCmd.Flag("inhibited", "Show the inhibited alerts").Default("true").BoolVar(&c.inhibited)
+	listCmd.Flag("fields", "Comma separated fields of the table output, in their order: "+fieldNames(alertFields)).PlaceHolder("labels,state").StringVar(&c.fields)
+	listCmd.Arg("matcher-groups", "Query filter").StringsVar(&c.matchers)
+	listCmd.Action(execWithTimeout(c.list))
+}
+
+// tenantAlerts holds the alerts returned for a given tenant.
+type tenantAlerts struct {
+	Tenant string                `json:"tenant"`
+	Alerts models.GettableAlerts `json:"alerts"`
+}
+
+func (c *alertListCmd) list(ctx context.Context, _ *kingpin.ParseContext) error {
+	matchers, err := parseMatchers(c.matchers)
+	if err != nil {
+		return err
+	}
+	filter := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		filter = append(filter, m.String())
+	}
+	fields, err := parseFields(c.fields, alertFields, defaultAlertFields, c.multiTenant())
+	if err != nil {
+		return err
+	}
+
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	var (
+		results []tenantAlerts
+		skipped []string
+	)
+	failed := 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	for i, t := range tenants {
+		if ctx.Err() != nil {
+			skipped = tenants[i:]
+			break
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		params := alert.NewGetAlertsParams().WithContext(ctx).WithTimeout(timeout).WithFilter(filter).
+			WithActive(&c.active).WithSilenced(&c.silenced).WithInhibited(&c.inhibited)
+		var getOk *alert.GetAlertsOK
+		err = withRetry(ctx, "query alerts"+forTenant(t), func() (err error) {
+			getOk, err = amclient.Alert.GetAlerts(params)
+			return err
+		})
+		if err != nil {
+			if !c.multiTenant() {
+				return fmt.Errorf("Unable to query alerts%s: %v", forTenant(t), err)
+			}
+			failed++
+			logger.Error("Unable to query alerts", "tenant", t, "err", err)
+			recordFailure(t, err)
+			continue
+		}
+		results = append(results, tenantAlerts{Tenant: t, Alerts: getOk.Payload})
+	}
+	if err := c.print(results, fields); err != nil {
+		return err
+	}
+
+	c.printSummary("queried", len(results), failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(len(results), fmt.Errorf("failed to query alerts for %d tenant(s)", failed))
+	}
+	return nil
+}
+
+func (c *alertListCmd) print(results []tenantAlerts, fields []tableField) error {
+	if output == "simple" {
+		return printAlerts(results, fields)
+	}
+
+	if structuredOutput() && c.multiTenant() {
+		return printStructured(results)
+	}
+
+	formatter, found := format.Formatters[output]
+	if !found {
+		return errors.New("unknown output formatter")
+	}
+	var alerts []*models.GettableAlert
+	for _, r := range results {
+		alerts = append(alerts, r.Alerts...)
+	}
+	return formatter.FormatAlerts(alerts)
+}
+
+// alertFields are the fields of the alerts in the table output.
+var alertFields = []tableField{
+	{"tenant", "Tenant", false},
+	{"fingerprint", "Fingerprint", false},
+	{"labels", "Labels", false},
+	{"annotations", "Annotations", false},
+	{"state", "State", false},
+	{"startsAt", "Starts At", false},
+	{"endsAt", "Ends At", false},
+	{"updatedAt", "Updated At", false},
+	{"receivers", "Receivers", false},
+	{"silencedBy", "Silenced By", false},
+	{"inhibitedBy", "Inhibited By", false},
+	{"generatorURL", "Generator URL", false},
+}
+
+// defaultAlertFields are the fields of the table output without --fields.
+var defaultAlertFields = []string{"labels", "state", "startsAt", "endsAt"}
+
+// alertFieldValue returns the value of the field of an alert of the tenant in
+// the table output.
+func alertFieldValue(name, tenant string, a *models.GettableAlert) string {
+	switch name {
+	case "tenant":
+		return tenant
+	case "fingerprint":
+		return *a.Fingerprint
+	case "labels":
+		return formatLabels(a.Labels)
+	case "annotations":
+		return formatLabels(a.Annotations)
+	case "state":
+		return *a.Status.State
+	case "startsAt":
+		return format.FormatDate(*a.StartsAt)
+	case "endsAt":
+		return format.FormatDate(*a.EndsAt)
+	case "updatedAt":
+		if a.UpdatedAt == nil {
+			return ""
+		}
+		return format.FormatDate(*a.UpdatedAt)
+	case "receivers":
+		names := make([]string, 0, len(a.Receivers))
+		for _, r := range a.Receivers {
+			names = append(names, *r.Name)
+		}
+		return strings.Join(names, ",")
+	case "silencedBy":
+		return strings.Join(a.Status.SilencedBy, ",")
+	case "inhibitedBy":
+		return strings.Join(a.Status.InhibitedBy, ",")
+	case "generatorURL":
+		return a.GeneratorURL.String()
+	}
+	return ""
+}
+
+// printAlerts writes the alerts as a table with the given fields.
+func printAlerts(results []tenantAlerts, fields []tableField) error {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	writeTableHeader(w, fields)
+	for _, r := range results {
+		for _, a := range r.Alerts {
+			for _, f := range fields {
+				fmt.Fprintf(w, "%s\t", alertFieldValue(f.name, r.Tenant, a))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return w.Flush()
+}
+
+// formatLabels renders the labels sorted by name, with quoted values.
+func formatLabels(ls models.LabelSet) string {
+	names := make([]string, 0, len(ls))
+	for name := range ls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output := make([]string, 0, len(names))
+	for _, name := range names {
+		output = append(output, name+"="+strconv.Quote(ls[name]))
+	}
+	return strings.Join(output, " ")
+}
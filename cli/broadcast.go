@@ -0,0 +1,128 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/general"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/prometheus/alertmanager/api/v2/models"
+)
+
+// broadcastSilence adds the silence to every peer of the Alertmanager
+// cluster instead of letting it gossip, and returns the distinct IDs of the
+// silences added, comma separated. It fails only when no peer got it.
+func (c *silenceAddCmd) broadcastSilence(ctx context.Context, amclient *client.AlertmanagerAPI, tenant string, ps *models.PostableSilence) (string, error) {
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return "", err
+	}
+	amURLs, httpConfig, err := c.clientConfig(httpConfig, tenant)
+	if err != nil {
+		return "", err
+	}
+	addresses, err := clusterPeers(ctx, amclient, tenant)
+	if err != nil {
+		return "", err
+	}
+	peers := []*url.URL{amURLs[0]}
+	if len(addresses) == 0 {
+		logger.Warn("No cluster peer found, the silence is only added to the Alertmanager", "tenant", tenant)
+	} else {
+		peers = peers[:0]
+		for _, a := range addresses {
+			peers = append(peers, peerURL(amURLs[0], a))
+		}
+	}
+
+	var (
+		ids     []string
+		seen    = map[string]bool{}
+		lastErr error
+	)
+	for _, u := range peers {
+		cr, err := newClientRuntime(u, *httpConfig)
+		if err != nil {
+			return "", err
+		}
+		pc := c.tenantClient(cr, tenant)
+		params := silence.NewPostSilencesParams().WithContext(ctx).WithTimeout(timeout).WithSilence(ps)
+		var postOk *silence.PostSilencesOK
+		err = withRetry(ctx, fmt.Sprintf("add silence to peer '%s'%s", u.Host, forTenant(tenant)), func() (err error) {
+			postOk, err = pc.Silence.PostSilences(params)
+			return err
+		})
+		if err != nil {
+			lastErr = err
+			logger.Warn("Unable to add silence to peer", "tenant", tenant, "peer", u.Host, "err", err)
+			continue
+		}
+		if id := postOk.Payload.SilenceID; !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return "", fmt.Errorf("unable to add silence to any peer: %v", lastErr)
+	}
+	return strings.Join(ids, ","), nil
+}
+
+// clusterPeers returns the cluster addresses of the peers.
+func clusterPeers(ctx context.Context, amclient *client.AlertmanagerAPI, tenant string) ([]string, error) {
+	params := general.NewGetStatusParams().WithContext(ctx).WithTimeout(timeout)
+	var getOk *general.GetStatusOK
+	err := withRetry(ctx, "get status"+forTenant(tenant), func() (err error) {
+		getOk, err = amclient.General.GetStatus(params)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get cluster peers: %v", err)
+	}
+
+	if getOk.Payload.Cluster == nil {
+		return nil, nil
+	}
+	var addresses []string
+	for _, p := range getOk.Payload.Cluster.Peers {
+		if p != nil && p.Address != nil {
+			addresses = append(addresses, *p.Address)
+		}
+	}
+	return addresses, nil
+}
+
+// peerURL returns the URL of the API of a peer. The peers are known by their
+// cluster address, the API is expected on the same port and path as the ones
+// of the Alertmanager URL.
+func peerURL(amURL *url.URL, peerAddress string) *url.URL {
+	address, basePath, scheme := amAddress(amURL)
+	_, port, _ := net.SplitHostPort(address)
+	host, _, err := net.SplitHostPort(peerAddress)
+	if err != nil {
+		host = peerAddress
+	}
+	return &url.URL{
+		Scheme: scheme,
+		User:   amURL.User,
+		Host:   net.JoinHostPort(host, port),
+		Path:   strings.TrimSuffix(basePath, defaultAmApiv2path),
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/alecthomas/kingpin/v2"
+	"gopkg.in/yaml.v2"
+)
+
+// configFile is the config file given by --config.file, read instead of the
+// default ones.
+var configFile string
+
+// noConfig is set by --no-config to read no config file at all.
+var noConfig bool
+
+// configTenants are the tenants of the config file, used when no tenant is
+// given by the flags.
+var configTenants []string
+
+// configSources are the config files the settings were read from, by flag
+// name, and 'tenants' for configTenants.
+var configSources map[string]string
+
+// configFilesRead are the config files which exist and were read.
+var configFilesRead []string
+
+// configValue is the value of a config file setting, either a scalar or a
+// list for the repeatable flags.
+type configValue []string
+
+func (v *configValue) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var s string
+	if err := unmarshal(&s); err == nil {
+		*v = configValue{s}
+		return nil
+	}
+	var l []string
+	if err := unmarshal(&l); err != nil {
+		return err
+	}
+	*v = l
+	return nil
+}
+
+// configResolver sets the defaults of the flags from the config files, like
+// the amtool resolver, and also reads the list of tenants.
+type configResolver struct {
+	flags   map[string][]string
+	tenants []string
+	sources map[string]string
+	read    []string
+}
+
+// newConfigResolver reads the config files, the first ones taking precedence
+// over the next ones. The settings named after a key of aliases are set to
+// the flag it maps to.
+func newConfigResolver(files []string, aliases map[string]string) (*configResolver, error) {
+	r := &configResolver{flags: map[string][]string{}, sources: map[string]string{}}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		var m map[string]configValue
+		if err := yaml.Unmarshal(b, &m); err != nil {
+			return nil, err
+		}
+		r.read = append(r.read, f)
+		for k, v := range m {
+			if k == "tenants" {
+				if r.tenants == nil {
+					r.tenants = v
+					r.sources[k] = f
+				}
+				continue
+			}
+			if flag, ok := aliases[k]; ok {
+				if _, ok := m[flag]; ok {
+					continue
+				}
+				k = flag
+			}
+			if _, ok := r.flags[k]; !ok {
+				r.flags[k] = v
+				r.sources[k] = f
+			}
+		}
+	}
+	return r, nil
+}
+
+// selectedConfigFiles returns the config file given by --config.file or
+// ATM_CONFIG_FILE, which must exist, none with --no-config, or the default
+// config files. The flags are looked up before parsing, as the config sets
+// the defaults of the flags.
+func selectedConfigFiles(app *kingpin.Application, args []string) ([]string, error) {
+	file := os.Getenv("ATM_CONFIG_FILE")
+	noConfig, _ := strconv.ParseBool(os.Getenv("ATM_NO_CONFIG"))
+	// The parse errors are reported when binding the config.
+	if pc, err := app.ParseContext(args); err == nil {
+		for _, elem := range pc.Elements {
+			f, ok := elem.Clause.(*kingpin.FlagClause)
+			if !ok || elem.Value == nil {
+				continue
+			}
+			switch f.Model().Name {
+			case "config.file":
+				file = *elem.Value
+			case "no-config":
+				noConfig, _ = strconv.ParseBool(*elem.Value)
+			}
+		}
+	}
+	if noConfig {
+		if file != "" {
+			return nil, errors.New("no-config and config.file are mutually exclusive")
+		}
+		return nil, nil
+	}
+	if file == "" {
+		return configFiles, nil
+	}
+	if _, err := os.Stat(file); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("config file '%s' not found", file)
+		}
+		return nil, err
+	}
+	return []string{file}, nil
+}
+
+type getFlagger interface {
+	GetFlag(name string) *kingpin.FlagClause
+}
+
+func (r *configResolver) setDefault(v getFlagger) {
+	for name, values := range r.flags {
+		if f := v.GetFlag(name); f != nil {
+			f.Default(values...)
+		}
+	}
+}
+
+// bind sets the defaults of the flags of the application and of the selected
+// command.
+func (r *configResolver) bind(app *kingpin.Application, args []string) error {
+	pc, err := app.ParseContext(args)
+	if err != nil {
+		return err
+	}
+
+	r.setDefault(app)
+	if pc.SelectedCommand != nil {
+		r.setDefault(pc.SelectedCommand)
+	}
+	configTenants = r.tenants
+	configSources = r.sources
+	configFilesRead = r.read
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/api/v2/client/general"
+)
+
+type configShowCmd struct {
+	redact bool
+	tenantFlags
+}
+
+const configShowHelp = `Show the Alertmanager configuration
+
+  The original configuration returned by the status endpoint is printed,
+  to check the receivers and routes before adding silences. It's written
+  as YAML with --output=yaml, and otherwise as one 'path: value' line per
+  setting, which can be filtered with grep:
+
+  atm config show --redact | grep receivers
+
+	Alertmanager already hides its secrets, --redact also hides the values
+	of the settings whose name looks like a credential, like passwords,
+	tokens, keys and API URLs.
+`
+
+// configureConfigCmd represents the config command.
+func configureConfigCmd(app *kingpin.Application) {
+	configCmd := app.Command("config", "Show the Alertmanager configuration, or the effective configuration of atm. For more information and additional flags see help")
+	configureConfigShowCmd(configCmd)
+	configureConfigViewCmd(app, configCmd)
+}
+
+func configureConfigShowCmd(cc *kingpin.CmdClause) {
+	var (
+		c       = &configShowCmd{}
+		showCmd = cc.Command("show", configShowHelp).PreAction(requireAlertManagerURL)
+	)
+	c.tenantFlags.configure(showCmd)
+	showCmd.Flag("redact", "Redact the values of the settings looking like credentials").BoolVar(&c.redact)
+	showCmd.Action(execWithTimeout(c.show))
+}
+
+// tenantConfigJSON is the JSON representation of the configuration of a
+// tenant.
+type tenantConfigJSON struct {
+	Tenant string `json:"tenant,omitempty"`
+	Config string `json:"config"`
+
+	settings yaml.MapSlice
+}
+
+func (c *configShowCmd) show(ctx context.Context, _ *kingpin.ParseContext) error {
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	var (
+		results []tenantConfigJSON
+		skipped []string
+	)
+	failed := 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	for i, t := range tenants {
+		if ctx.Err() != nil {
+			skipped = tenants[i:]
+			break
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		params := general.NewGetStatusParams().WithContext(ctx).WithTimeout(timeout)
+		var getOk *general.GetStatusOK
+		err = withRetry(ctx, "get status"+forTenant(t), func() (err error) {
+			getOk, err = amclient.General.GetStatus(params)
+			return err
+		})
+		if err == nil && (getOk.Payload.Config == nil || getOk.Payload.Config.Original == nil) {
+			err = fmt.Errorf("no configuration in the status")
+		}
+		var config yaml.MapSlice
+		if err == nil {
+			err = yaml.Unmarshal([]byte(*getOk.Payload.Config.Original), &config)
+		}
+		if err != nil {
+			if !c.multiTenant() {
+				return fmt.Errorf("Unable to get configuration%s: %v", forTenant(t), err)
+			}
+			failed++
+			logger.Error("Unable to get configuration", "tenant", t, "err", err)
+			recordFailure(t, err)
+			continue
+		}
+
+		if c.redact {
+			config = redactConfig(config)
+		}
+		b, err := yaml.Marshal(config)
+		if err != nil {
+			return err
+		}
+		results = append(results, tenantConfigJSON{Tenant: t, Config: string(b), settings: config})
+	}
+	if err := c.print(results); err != nil {
+		return err
+	}
+
+	c.printSummary("shown", len(results), failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(len(results), fmt.Errorf("failed to get configuration for %d tenant(s)", failed))
+	}
+	return nil
+}
+
+func (c *configShowCmd) print(results []tenantConfigJSON) error {
+	withTenant := c.multiTenant()
+	switch output {
+	case "json":
+		if withTenant {
+			return printJSON(results)
+		}
+		for _, r := range results {
+			if err := printJSON(r); err != nil {
+				return err
+			}
+		}
+	case "yaml":
+		for i, r := range results {
+			if withTenant {
+				if i > 0 {
+					fmt.Fprintln(stdout, "---")
+				}
+				fmt.Fprintf(stdout, "# tenant: %s\n", r.Tenant)
+			}
+			fmt.Fprint(stdout, r.Config)
+		}
+	default:
+		for _, r := range results {
+			prefix := ""
+			if withTenant {
+				prefix = r.Tenant + " "
+			}
+			printConfigLines(prefix, "", r.settings)
+		}
+	}
+	return nil
+}
+
+// printConfigLines writes a 'path: value' line for each setting of v.
+func printConfigLines(prefix, path string, v interface{}) {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		for _, item := range v {
+			key := fmt.Sprint(item.Key)
+			if path != "" {
+				key = path + "." + key
+			}
+			printConfigLines(prefix, key, item.Value)
+		}
+	case []interface{}:
+		for i, item := range v {
+			printConfigLines(prefix, fmt.Sprintf("%s[%d]", path, i), item)
+		}
+	default:
+		fmt.Fprintf(stdout, "%s%s: %v\n", prefix, path, v)
+	}
+}
+
+// secretSetting matches the names of the settings holding credentials.
+var secretSetting = regexp.MustCompile(`(?i)(password|secret|token|api_key|api_url|routing_key|service_key|credentials|webhook_url)`)
+
+// redactConfig replaces the values of the settings looking like credentials,
+// and the credentials of the URLs.
+func redactConfig(config yaml.MapSlice) yaml.MapSlice {
+	return redactValue("", config).(yaml.MapSlice)
+}
+
+func redactValue(key string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		out := make(yaml.MapSlice, 0, len(v))
+		for _, item := range v {
+			out = append(out, yaml.MapItem{Key: item.Key, Value: redactValue(fmt.Sprint(item.Key), item.Value)})
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			out = append(out, redactValue(key, item))
+		}
+		return out
+	case string:
+		if secretSetting.MatchString(key) && !strings.HasSuffix(key, "_file") {
+			return redacted
+		}
+		return redactURL(v)
+	}
+	return v
+}
+
+// urlCredentials matches the password of a URL with credentials.
+var urlCredentials = regexp.MustCompile(`^([a-zA-Z][a-zA-Z0-9+.-]*://[^/:@]+:)[^/@]+@`)
+
+// redactURL hides the password of a URL with credentials.
+func redactURL(s string) string {
+	return urlCredentials.ReplaceAllString(s, "${1}"+redacted+"@")
+}
@@ -0,0 +1,241 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setConfigFiles sets the default config files for the test, so that the
+// config files of the user running the tests are never read.
+func setConfigFiles(t *testing.T, files ...string) {
+	t.Helper()
+	old, oldURLs := configFiles, alertmanagerURLs
+	configFiles, alertmanagerURLs = files, nil
+	t.Cleanup(func() { configFiles, alertmanagerURLs = old, oldURLs })
+}
+
+// writeConfigFile writes the config file in a temporary directory, and
+// returns its path.
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// viewConfig runs config view, reading the config files, and returns the
+// effective settings by name.
+func viewConfig(t *testing.T, args ...string) map[string]configSettingJSON {
+	t.Helper()
+	stdout, stderr, err := runWithConfig(t, append([]string{"--output=json", "config", "view"}, args...)...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v (%s)", err, stderr)
+	}
+	var settings []configSettingJSON
+	if err := json.Unmarshal([]byte(stdout), &settings); err != nil {
+		t.Fatalf("expected a JSON list, got %q (%v)", stdout, err)
+	}
+	byName := map[string]configSettingJSON{}
+	for _, s := range settings {
+		byName[s.Name] = s
+	}
+	return byName
+}
+
+func TestAlertmanagerURLSources(t *testing.T) {
+	configFile := writeConfigFile(t, "alertmanager.url: http://from-config:9093\n")
+
+	for _, tc := range []struct {
+		name          string
+		env           map[string]string
+		args          []string
+		value, source string
+	}{
+		{
+			name:   "config file",
+			value:  "http://from-config:9093",
+			source: "config file " + configFile,
+		},
+		{
+			name:   "environment variable",
+			env:    map[string]string{"ALERTMANAGER_URL": "http://from-env:9093"},
+			value:  "http://from-env:9093",
+			source: "env $ALERTMANAGER_URL",
+		},
+		{
+			name:   "atm environment variable",
+			env:    map[string]string{"ALERTMANAGER_URL": "http://from-env:9093", "ATM_ALERTMANAGER_URL": "http://from-atm-env:9093"},
+			value:  "http://from-atm-env:9093",
+			source: "env $ATM_ALERTMANAGER_URL",
+		},
+		{
+			name:   "flag",
+			env:    map[string]string{"ALERTMANAGER_URL": "http://from-env:9093"},
+			args:   []string{"--alertmanager.url=http://from-flag:9093"},
+			value:  "http://from-flag:9093",
+			source: "flag --alertmanager.url",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			setConfigFiles(t, configFile)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			s := viewConfig(t, tc.args...)["alertmanager.url"]
+			if s.Value != tc.value || s.Source != tc.source {
+				t.Fatalf("expected %s from %s, got %s from %s", tc.value, tc.source, s.Value, s.Source)
+			}
+		})
+	}
+}
+
+func TestEnvarSources(t *testing.T) {
+	configFile := writeConfigFile(t, "timeout: 10s\ntenant.http-header: X-Config-Tenant\nretries: 2\n")
+
+	for _, tc := range []struct {
+		name          string
+		setting       string
+		env           map[string]string
+		args          []string
+		value, source string
+	}{
+		{name: "default", setting: "log.level", value: "info", source: "default"},
+		{name: "config file", setting: "timeout", value: "10s", source: "config file " + configFile},
+		{name: "envar over config file", setting: "timeout", env: map[string]string{"ATM_TIMEOUT": "20s"}, value: "20s", source: "env $ATM_TIMEOUT"},
+		{name: "flag over envar", setting: "timeout", env: map[string]string{"ATM_TIMEOUT": "20s"}, args: []string{"--timeout=40s"}, value: "40s", source: "flag --timeout"},
+		{name: "envar of a dotted flag", setting: "tenant.http-header", env: map[string]string{"ATM_TENANT_HTTP_HEADER": "X-Env-Tenant"}, value: "X-Env-Tenant", source: "env $ATM_TENANT_HTTP_HEADER"},
+		{name: "config file of a dotted flag", setting: "tenant.http-header", value: "X-Config-Tenant", source: "config file " + configFile},
+		{name: "envar of a dashed flag", setting: "retry-backoff", env: map[string]string{"ATM_RETRY_BACKOFF": "3s"}, value: "3s", source: "env $ATM_RETRY_BACKOFF"},
+		{name: "envar of a boolean flag", setting: "quiet", env: map[string]string{"ATM_QUIET": "true"}, value: "true", source: "env $ATM_QUIET"},
+		{name: "envar of a command flag", setting: "tenant", env: map[string]string{"ATM_TENANT": "tenant-a"}, value: "tenant-a", source: "env $ATM_TENANT"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			setConfigFiles(t, configFile)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			s := viewConfig(t, tc.args...)[tc.setting]
+			if s.Value != tc.value || s.Source != tc.source {
+				t.Fatalf("expected %s from %s, got %q from %q", tc.value, tc.source, s.Value, s.Source)
+			}
+		})
+	}
+}
+
+func TestConfigFileFlag(t *testing.T) {
+	var (
+		defaultFile  = writeConfigFile(t, "timeout: 10s\nretries: 2\n")
+		explicitFile = writeConfigFile(t, "timeout: 20s\n")
+	)
+
+	// The default config files are searched without --config.file.
+	setConfigFiles(t, filepath.Join(t.TempDir(), "missing.yml"), defaultFile)
+	settings := viewConfig(t)
+	if s := settings["timeout"]; s.Value != "10s" || s.Source != "config file "+defaultFile {
+		t.Fatalf("expected the timeout of the default config file, got %s from %s", s.Value, s.Source)
+	}
+
+	// --config.file and $ATM_CONFIG_FILE are read instead of the default ones.
+	for _, tc := range []struct {
+		name string
+		env  string
+		args []string
+	}{
+		{name: "flag", args: []string{"--config.file", explicitFile}},
+		{name: "envar", env: explicitFile},
+		{name: "flag over envar", env: defaultFile, args: []string{"--config.file", explicitFile}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			setConfigFiles(t, defaultFile)
+			if tc.env != "" {
+				t.Setenv("ATM_CONFIG_FILE", tc.env)
+			}
+			settings := viewConfig(t, tc.args...)
+			if s := settings["timeout"]; s.Value != "20s" || s.Source != "config file "+explicitFile {
+				t.Fatalf("expected the timeout of --config.file, got %s from %s", s.Value, s.Source)
+			}
+			if s := settings["retries"]; s.Source != "default" {
+				t.Fatalf("expected the default config file to be ignored, got retries from %s", s.Source)
+			}
+		})
+	}
+}
+
+func TestConfigFileFlagMissing(t *testing.T) {
+	setConfigFiles(t)
+	missing := filepath.Join(t.TempDir(), "missing.yml")
+
+	_, _, err := runWithConfig(t, "--config.file", missing, "config", "view")
+	if err == nil || !strings.Contains(err.Error(), "config file '"+missing+"' not found") {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
+
+func TestUserConfigDir(t *testing.T) {
+	t.Setenv("HOME", "/home/atm")
+
+	t.Setenv("XDG_CONFIG_HOME", "/var/lib/atm/config")
+	if dir := userConfigDir(); dir != "/var/lib/atm/config" {
+		t.Fatalf("expected $XDG_CONFIG_HOME, got %s", dir)
+	}
+	t.Setenv("XDG_CONFIG_HOME", "")
+	if dir := userConfigDir(); dir != "/home/atm/.config" {
+		t.Fatalf("expected $HOME/.config, got %s", dir)
+	}
+}
+
+func TestNoConfig(t *testing.T) {
+	configFile := writeConfigFile(t, "timeout: 10s\ntenants: [tenant-a, tenant-b]\n")
+
+	setConfigFiles(t, configFile)
+	if s := viewConfig(t)["tenants"]; s.Value != "tenant-a,tenant-b" {
+		t.Fatalf("expected the tenants of the config file, got %q", s.Value)
+	}
+
+	for _, tc := range []struct {
+		name string
+		env  map[string]string
+		args []string
+	}{
+		{name: "flag", args: []string{"--no-config"}},
+		{name: "envar", env: map[string]string{"ATM_NO_CONFIG": "true"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			setConfigFiles(t, configFile)
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+			settings := viewConfig(t, tc.args...)
+			if s := settings["timeout"]; s.Value != "30s" || s.Source != "default" {
+				t.Fatalf("expected the default timeout, got %s from %s", s.Value, s.Source)
+			}
+			if _, ok := settings["tenants"]; ok {
+				t.Fatal("expected no tenants from the config file")
+			}
+		})
+	}
+
+	setConfigFiles(t, configFile)
+	_, _, err := runWithConfig(t, "--no-config", "--config.file", configFile, "config", "view")
+	if err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected a mutually exclusive error, got %v", err)
+	}
+}
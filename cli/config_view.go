@@ -0,0 +1,139 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+type configViewCmd struct {
+	app *kingpin.Application
+	tenantFlags
+}
+
+const configViewHelp = `Show the effective configuration of atm
+
+  Each setting is printed with its value and where it comes from: the
+  command line flag, the environment variable, the config file or the
+  default. Nothing is sent to Alertmanager.
+
+  ATM_TIMEOUT=10s atm config view --tenant tenant-a
+
+	The bearer token, the OAuth2 client secret and the header values are
+	redacted, unless --verbose.show-secrets is set. The passwords of the URLs
+	always are.
+`
+
+// secretFlags are the flags whose values are redacted by config view.
+var secretFlags = map[string]bool{"bearer-token": true, "oauth2.client-secret": true, "header": true}
+
+func configureConfigViewCmd(app *kingpin.Application, cc *kingpin.CmdClause) {
+	var (
+		c       = &configViewCmd{app: app}
+		viewCmd = cc.Command("view", configViewHelp)
+	)
+	c.tenantFlags.configure(viewCmd)
+	viewCmd.Action(c.view)
+}
+
+// configSettingJSON is the JSON representation of an effective setting.
+type configSettingJSON struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+func (c *configViewCmd) view(pc *kingpin.ParseContext) error {
+	onCommandLine := map[string]bool{}
+	for _, elem := range pc.Elements {
+		if f, ok := elem.Clause.(*kingpin.FlagClause); ok {
+			onCommandLine[f.Model().Name] = true
+		}
+	}
+
+	var settings []configSettingJSON
+	flags := pc.SelectedCommand.Model().Flags
+	for _, f := range append(c.app.Model().Flags, flags...) {
+		if f.Hidden || f.Name == "help" || f.Name == "version" {
+			continue
+		}
+		value := f.Value.String()
+		if secretFlags[f.Name] && value != "" && value != "[]" && !verboseShowSecrets {
+			value = redacted
+		}
+		settings = append(settings, configSettingJSON{Name: f.Name, Value: value, Source: flagSource(f, onCommandLine)})
+	}
+	if c.tenant == "" && c.tenantFile == "" && len(configTenants) > 0 {
+		settings = append(settings, configSettingJSON{
+			Name:   "tenants",
+			Value:  strings.Join(configTenants, ","),
+			Source: "config file " + configSources["tenants"],
+		})
+	}
+	settings = append(settings, configSettingJSON{Name: "auth", Value: authSource(), Source: "resolved"})
+
+	if structuredOutput() {
+		return printStructured(settings)
+	}
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Setting\tValue\tSource")
+	for _, s := range settings {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", s.Name, s.Value, s.Source)
+	}
+	return w.Flush()
+}
+
+// flagSource returns where the value of the flag comes from, in the order of
+// precedence.
+func flagSource(f *kingpin.FlagModel, onCommandLine map[string]bool) string {
+	if onCommandLine[f.Name] {
+		return "flag --" + f.Name
+	}
+	if f.Envar != "" && os.Getenv(f.Envar) != "" {
+		return "env $" + f.Envar
+	}
+	if file, ok := configSources[f.Name]; ok {
+		return "config file " + file
+	}
+	return "default"
+}
+
+// authSource returns how atm authenticates to Alertmanager.
+func authSource() string {
+	for _, u := range alertmanagerURLs {
+		if u.User != nil {
+			return "basic auth of --alertmanager.url"
+		}
+	}
+	switch {
+	case httpConfigFile != "":
+		return "http.config.file"
+	case httpConfigDir != "":
+		return "http.config.dir"
+	case bearerTokenFile != "":
+		return "bearer-token-file"
+	case bearerToken != "":
+		return "bearer-token"
+	case oauth2ClientID != "":
+		return "oauth2"
+	case tlsCertFile != "":
+		return "tls client certificate"
+	}
+	return "none"
+}
@@ -0,0 +1,295 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-openapi/runtime"
+	promconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/alertmanager/api/v2/client/general"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+)
+
+type doctorCmd struct {
+	app *kingpin.Application
+	tenantFlags
+}
+
+const doctorHelp = `Check the configuration and the connection to Alertmanager
+
+  Each check is printed as passed, failed or skipped, with a hint to fix
+  the failed ones:
+
+  atm doctor --tenant tenant-a
+
+	The config files, the HTTP config, the Alertmanager URLs, the
+	credentials and the tenant header are checked. Only the status and the
+	silences are read, nothing is changed, so it's safe to run anytime.
+`
+
+// configureDoctorCmd represents the doctor command.
+func configureDoctorCmd(app *kingpin.Application) {
+	var (
+		c   = &doctorCmd{app: app}
+		cmd = app.Command("doctor", doctorHelp)
+	)
+	c.tenantFlags.configure(cmd)
+	cmd.Action(execWithTimeout(c.doctor))
+}
+
+const (
+	checkPass = "pass"
+	checkFail = "fail"
+	checkSkip = "skip"
+)
+
+// doctorCheck is the outcome of a check of the doctor command.
+type doctorCheck struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Hint   string `json:"hint,omitempty"`
+}
+
+func (c *doctorCmd) doctor(ctx context.Context, _ *kingpin.ParseContext) error {
+	checks := []doctorCheck{c.checkConfigFiles()}
+
+	httpConfig, check := checkHTTPConfig()
+	checks = append(checks, check)
+
+	tenant := ""
+	tenants, err := c.tenants()
+	if err != nil {
+		checks = append(checks, doctorCheck{
+			Check: "tenants", Status: checkFail, Detail: err.Error(),
+			Hint: "fix the tenant file, or the tenant flags",
+		})
+	} else {
+		tenant = tenants[0]
+		checks = append(checks, doctorCheck{Check: "tenants", Status: checkPass, Detail: tenantsDetail(tenants)})
+	}
+
+	if len(alertmanagerURLs) == 0 {
+		checks = append(checks, doctorCheck{
+			Check: "alertmanager url", Status: checkFail, Detail: "no Alertmanager URL",
+			Hint: "set --alertmanager.url, $ALERTMANAGER_URL or alertmanager.url in the config file",
+		})
+	} else if httpConfig != nil {
+		usable := false
+		for _, u := range alertmanagerURLs {
+			urlChecks := c.checkURL(ctx, u, httpConfig, tenant)
+			usable = usable || urlChecks[1].Status == checkPass
+			checks = append(checks, urlChecks...)
+		}
+		switch {
+		case !usable:
+			checks = append(checks, doctorCheck{Check: "tenant header", Status: checkSkip, Detail: "no usable Alertmanager"})
+		case err == nil:
+			checks = append(checks, c.checkTenantHeader(ctx, httpConfig, tenant))
+		}
+	}
+
+	if err := printChecks(checks); err != nil {
+		return err
+	}
+	failed := 0
+	for _, check := range checks {
+		if check.Status == checkFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	return nil
+}
+
+// checkConfigFiles reports the config files read, and the settings of the
+// config files which aren't a flag of atm, being ignored.
+func (c *doctorCmd) checkConfigFiles() doctorCheck {
+	check := doctorCheck{Check: "config file", Status: checkPass, Detail: "no config file"}
+	if len(configFilesRead) > 0 {
+		check.Detail = "read " + strings.Join(configFilesRead, ", ")
+	}
+
+	known := map[string]bool{"tenants": true}
+	addFlags := func(flags []*kingpin.FlagModel) {
+		for _, f := range flags {
+			known[f.Name] = true
+		}
+	}
+	addFlags(c.app.Model().Flags)
+	var addCommands func(cmds []*kingpin.CmdModel)
+	addCommands = func(cmds []*kingpin.CmdModel) {
+		for _, cmd := range cmds {
+			addFlags(cmd.Flags)
+			addCommands(cmd.Commands)
+		}
+	}
+	addCommands(c.app.Model().Commands)
+
+	var unknown []string
+	for name, file := range configSources {
+		if !known[name] {
+			unknown = append(unknown, fmt.Sprintf("'%s' in %s", name, file))
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		check.Status = checkFail
+		check.Detail = "unknown settings " + strings.Join(unknown, ", ")
+		check.Hint = "the settings are named after the flags, see atm --help"
+	}
+	return check
+}
+
+// checkHTTPConfig loads the HTTP config, returning nil and a failed check if
+// it's invalid, like conflicting TLS or credentials flags.
+func checkHTTPConfig() (*promconfig.HTTPClientConfig, doctorCheck) {
+	check := doctorCheck{Check: "http config", Status: checkPass, Detail: "auth " + authSource()}
+	if httpConfigFile != "" {
+		if _, _, err := promconfig.LoadHTTPConfigFile(httpConfigFile); err != nil {
+			check.Status = checkFail
+			check.Detail = err.Error()
+			check.Hint = "fix --http.config.file, its format is https://prometheus.io/docs/alerting/latest/configuration/#http_config"
+			return nil, check
+		}
+	}
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		check.Status = checkFail
+		check.Detail = err.Error()
+		check.Hint = "fix the TLS, credentials and header flags, see atm --help"
+		return nil, check
+	}
+	return httpConfig, check
+}
+
+// tenantsDetail describes the tenants selected by the flags.
+func tenantsDetail(tenants []string) string {
+	switch {
+	case len(tenants) > 1:
+		return fmt.Sprintf("%d tenants, the first one is checked", len(tenants))
+	case tenants[0] == "":
+		return "no tenant"
+	}
+	return fmt.Sprintf("'%s' tenant", tenants[0])
+}
+
+// checkURL checks that the Alertmanager is reachable at the URL, and that it
+// accepts the credentials, by getting its status.
+func (c *doctorCmd) checkURL(ctx context.Context, u *url.URL, httpConfig *promconfig.HTTPClientConfig, tenant string) []doctorCheck {
+	var (
+		reachable = doctorCheck{Check: "reachable " + u.Redacted(), Status: checkPass}
+		auth      = doctorCheck{Check: "auth " + u.Redacted(), Status: checkPass, Detail: "credentials accepted"}
+	)
+	cr, err := newClientRuntime(u, *httpConfig)
+	if err != nil {
+		reachable.Status, reachable.Detail = checkSkip, "invalid credentials"
+		auth.Status, auth.Detail = checkFail, err.Error()
+		auth.Hint = "give the credentials either in the URL or with the flags"
+		return []doctorCheck{reachable, auth}
+	}
+	amclient := c.tenantClient(cr, tenant)
+	getOk, err := amclient.General.GetStatus(general.NewGetStatusParams().WithContext(ctx).WithTimeout(timeout))
+	if err == nil {
+		if getOk.Payload.VersionInfo != nil && getOk.Payload.VersionInfo.Version != nil {
+			reachable.Detail = "Alertmanager " + *getOk.Payload.VersionInfo.Version
+		}
+		return []doctorCheck{reachable, auth}
+	}
+
+	var status runtime.ClientResponseStatus
+	if !errors.As(err, &status) {
+		reachable.Status = checkFail
+		reachable.Detail = err.Error()
+		reachable.Hint = "check the URL, the network and the proxy settings, --verbose shows the requests"
+		auth.Status, auth.Detail = checkSkip, "Alertmanager unreachable"
+		return []doctorCheck{reachable, auth}
+	}
+	reachable.Detail = "responded"
+	switch {
+	case status.IsCode(401) || status.IsCode(403):
+		auth.Status = checkFail
+		auth.Detail = err.Error()
+		auth.Hint = fmt.Sprintf("check the credentials given by %s, a multi-tenant Alertmanager also rejects the requests without a tenant", authSource())
+		if authSource() == "none" {
+			auth.Hint = "no credentials are given, set them with --http.config.file, --bearer-token or the URL, a multi-tenant Alertmanager also rejects the requests without a tenant"
+		}
+	default:
+		reachable.Status = checkFail
+		reachable.Detail = err.Error()
+		reachable.Hint = "check the path of the URL, atm adds " + defaultAmApiv2path + " to it"
+		auth.Status, auth.Detail = checkSkip, "status unavailable"
+	}
+	return []doctorCheck{reachable, auth}
+}
+
+// checkTenantHeader checks that the tenant header is accepted, by getting
+// the silences of the tenant. Alertmanager doesn't echo the header, the
+// multi-tenant proxies reject the requests without a valid one.
+func (c *doctorCmd) checkTenantHeader(ctx context.Context, httpConfig *promconfig.HTTPClientConfig, tenant string) doctorCheck {
+	check := doctorCheck{Check: "tenant header", Status: checkSkip, Detail: "no tenant, no tenant header is sent"}
+	if tenant == "" {
+		return check
+	}
+	header := c.tenantHTTPHeader
+	if tc, ok := c.configs[tenant]; ok && tc.Header != "" {
+		header = tc.Header
+	}
+	sent := fmt.Sprintf("'%s: %s'", header, c.headerValue(tenant))
+
+	amclient, err := c.newClient(httpConfig, tenant)
+	if err != nil {
+		check.Status, check.Detail = checkFail, err.Error()
+		return check
+	}
+	if _, err := amclient.Silence.GetSilences(silence.NewGetSilencesParams().WithContext(ctx).WithTimeout(timeout)); err != nil {
+		check.Status = checkFail
+		check.Detail = fmt.Sprintf("%s rejected: %v", sent, err)
+		check.Hint = "check the tenant ID and --tenant.http-header, the header expected by Mimir and Cortex is X-Scope-OrgID"
+		return check
+	}
+	check.Status = checkPass
+	check.Detail = sent + " accepted"
+	return check
+}
+
+// printChecks writes the checks as JSON or YAML with --output=json and
+// --output=yaml, and otherwise as a checklist with the hints of the failed
+// checks.
+func printChecks(checks []doctorCheck) error {
+	if structuredOutput() {
+		return printStructured(checks)
+	}
+	for _, check := range checks {
+		fmt.Fprintf(stdout, "[%s] %s", strings.ToUpper(check.Status), check.Check)
+		if check.Detail != "" {
+			fmt.Fprintf(stdout, ": %s", check.Detail)
+		}
+		fmt.Fprintln(stdout)
+		if check.Hint != "" {
+			fmt.Fprintf(stdout, "       hint: %s\n", check.Hint)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// doctorChecks runs atm doctor with the arguments, and returns its checks by
+// name and its error.
+func doctorChecks(t *testing.T, args ...string) (map[string]doctorCheck, error) {
+	t.Helper()
+	stdout, _, err := runCommand(t, append([]string{"--output=json", "doctor", "--tenant", "tenant-a"}, args...)...)
+	var checks []doctorCheck
+	if jerr := json.Unmarshal([]byte(stdout), &checks); jerr != nil {
+		t.Fatalf("expected the checks as JSON, got %q (%v)", stdout, jerr)
+	}
+	byName := map[string]doctorCheck{}
+	for _, c := range checks {
+		byName[c.Check] = c
+	}
+	return byName, err
+}
+
+func TestDoctor(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	checks, err := doctorChecks(t)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, name := range []string{"config file", "http config", "tenants", "reachable " + am.URL, "auth " + am.URL, "tenant header"} {
+		if c, ok := checks[name]; !ok || c.Status != checkPass {
+			t.Fatalf("expected the %q check to pass, got %+v", name, checks)
+		}
+	}
+}
+
+func TestDoctorInvalidHTTPConfig(t *testing.T) {
+	newFakeAlertmanager(t)
+	for _, tc := range []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{
+			name: "bearer token and file",
+			args: []string{"--bearer-token=secret", "--bearer-token-file=/dev/null"},
+			err:  "bearer-token and bearer-token-file are mutually exclusive",
+		},
+		{
+			name: "tls cert without key",
+			args: []string{"--tls.cert-file=/dev/null"},
+			err:  "tls.cert-file and tls.key-file must be given together",
+		},
+		{
+			name: "oauth2 without token url",
+			args: []string{"--oauth2.client-id=atm"},
+			err:  "oauth2.client-id and oauth2.token-url are required for oauth2",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			// The global flags come before the command.
+			checks, err := doctorChecks(t, tc.args...)
+			if err == nil || !strings.Contains(err.Error(), "check(s) failed") {
+				t.Fatalf("expected failed checks, got %v", err)
+			}
+			if c := checks["http config"]; c.Status != checkFail || c.Detail != tc.err || c.Hint == "" {
+				t.Fatalf("expected the http config check to fail with %q, got %+v", tc.err, c)
+			}
+			if _, ok := checks["tenants"]; !ok {
+				t.Fatalf("expected the next checks to run, got %+v", checks)
+			}
+		})
+	}
+}
+
+func TestDoctorBasicAuthConflict(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	u, err := url.Parse(am.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.User = url.UserPassword("atm", "secret")
+	alertmanagerURLs = urlListValue{u}
+
+	checks, err := doctorChecks(t, "--bearer-token=secret")
+	if err == nil {
+		t.Fatal("expected failed checks")
+	}
+	if c := checks["auth "+u.Redacted()]; c.Status != checkFail || !strings.Contains(c.Detail, "basic authentication and bearer token are mutually exclusive") {
+		t.Fatalf("expected the auth check to fail, got %+v", checks)
+	}
+}
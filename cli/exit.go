@@ -0,0 +1,126 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+const (
+	// exitPartialFailure is the exit code of a command which failed for
+	// some tenants or silences only, other errors exit with 1.
+	exitPartialFailure = 2
+	// exitDeadline is the exit code of a command which reached --deadline
+	// before processing all the tenants.
+	exitDeadline = 3
+)
+
+// codeError is an error making atm exit with a specific code.
+type codeError struct {
+	code int
+	err  error
+}
+
+func (e *codeError) Error() string { return e.err.Error() }
+
+func (e *codeError) Unwrap() error { return e.err }
+
+// failure returns the error of a run where some operations failed. It's a
+// partial failure when done operations succeeded.
+func failure(done int, err error) error {
+	if done > 0 {
+		return &codeError{code: exitPartialFailure, err: err}
+	}
+	return err
+}
+
+// errorFormat is the format of the error printed when atm fails, text or
+// json.
+var errorFormat string
+
+// tenantFailure is the failure of a tenant in a multi-tenant run.
+type tenantFailure struct {
+	Tenant string `json:"tenant"`
+	Error  string `json:"error"`
+}
+
+var (
+	failuresMtx sync.Mutex
+	failures    []tenantFailure
+)
+
+// recordFailure records the failure of the tenant, reported with
+// --error-format=json.
+func recordFailure(tenant string, err error) {
+	failuresMtx.Lock()
+	defer failuresMtx.Unlock()
+	failures = append(failures, tenantFailure{Tenant: tenant, Error: err.Error()})
+}
+
+// errorJSON is the error printed with --error-format=json. The tenant is set
+// when a single tenant failed.
+type errorJSON struct {
+	Error    string          `json:"error"`
+	Tenant   string          `json:"tenant,omitempty"`
+	Code     int             `json:"code"`
+	Failures []tenantFailure `json:"failures,omitempty"`
+}
+
+// fatal prints the error to stderr in the --error-format and exits with its
+// code, 1 unless it's a codeError.
+func fatal(app *kingpin.Application, err error) {
+	os.Exit(printError(os.Stderr, app, err))
+}
+
+// printError writes the error to w in the --error-format, and returns the
+// exit code of the error.
+func printError(w io.Writer, app *kingpin.Application, err error) int {
+	code := exitCode(err)
+	if errorFormat != "json" {
+		fmt.Fprintf(w, "%s: error: %v\n", app.Name, err)
+		return code
+	}
+
+	e := errorJSON{Error: err.Error(), Code: code, Failures: failures}
+	for i, f := range failures {
+		if i > 0 && f.Tenant != e.Tenant {
+			e.Tenant = ""
+			break
+		}
+		e.Tenant = f.Tenant
+	}
+	b, jerr := json.Marshal(e)
+	if jerr != nil {
+		fmt.Fprintf(w, "%s: error: %v\n", app.Name, err)
+		return code
+	}
+	fmt.Fprintln(w, string(b))
+	return code
+}
+
+// exitCode returns the exit code of the error, 1 unless it's a codeError.
+func exitCode(err error) int {
+	var ce *codeError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	return 1
+}
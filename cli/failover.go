@@ -0,0 +1,112 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/go-openapi/runtime"
+	promconfig "github.com/prometheus/common/config"
+)
+
+// urlListValue is a repeatable URL flag value, also accepting comma separated
+// URLs.
+type urlListValue []*url.URL
+
+func (v *urlListValue) Set(s string) error {
+	for _, raw := range strings.Split(s, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("invalid URL '%s': %v", raw, err)
+		}
+		*v = append(*v, u)
+	}
+	return nil
+}
+
+func (v *urlListValue) String() string {
+	urls := make([]string, 0, len(*v))
+	for _, u := range *v {
+		urls = append(urls, u.Redacted())
+	}
+	return strings.Join(urls, ",")
+}
+
+func (v *urlListValue) IsCumulative() bool { return true }
+
+// newTransport returns the transport sending each request to the first
+// Alertmanager of the list, then to the next ones when it's down.
+func newTransport(amURLs []*url.URL, httpConfig promconfig.HTTPClientConfig) (runtime.ClientTransport, error) {
+	if len(amURLs) == 1 {
+		return newClientRuntime(amURLs[0], httpConfig)
+	}
+	ft := &failoverTransport{urls: amURLs}
+	for _, u := range amURLs {
+		cr, err := newClientRuntime(u, httpConfig)
+		if err != nil {
+			return nil, err
+		}
+		ft.runtimes = append(ft.runtimes, cr)
+	}
+	return ft, nil
+}
+
+// failoverTransport submits the operations to each Alertmanager in turn,
+// until one of them is reachable and doesn't fail with a server error. Client
+// errors are returned right away, the next Alertmanager would reject the
+// request too.
+type failoverTransport struct {
+	urls     []*url.URL
+	runtimes []runtime.ClientTransport
+}
+
+func (f *failoverTransport) Submit(op *runtime.ClientOperation) (interface{}, error) {
+	var (
+		result interface{}
+		err    error
+	)
+	for i, rt := range f.runtimes {
+		result, err = rt.Submit(op)
+		if err == nil || !isFailover(err) || (op.Context != nil && op.Context.Err() != nil) {
+			if verbose {
+				verboseMtx.Lock()
+				fmt.Fprintf(os.Stderr, "< %s %s served by %s\n", op.Method, op.PathPattern, f.urls[i].Redacted())
+				verboseMtx.Unlock()
+			}
+			return result, err
+		}
+		if i < len(f.runtimes)-1 {
+			logger.Warn("Alertmanager unavailable, trying the next one", "url", f.urls[i].Redacted(), "next", f.urls[i+1].Redacted(), "err", err)
+		}
+	}
+	return result, err
+}
+
+// isFailover reports whether the error is a connection or a server error,
+// for which the request is sent to the next Alertmanager.
+func isFailover(err error) bool {
+	var status runtime.ClientResponseStatus
+	if errors.As(err, &status) {
+		return status.IsServerError()
+	}
+	return true
+}
@@ -0,0 +1,438 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	promconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/cli/format"
+)
+
+// fakeRequest is a request received by the fake Alertmanager.
+type fakeRequest struct {
+	method, path, tenant, auth string
+}
+
+// fakeAlertmanager serves the silences API of Alertmanager, the silences of
+// each tenant, given by the X-Scope-OrgID header, being kept apart.
+type fakeAlertmanager struct {
+	*httptest.Server
+
+	mtx      sync.Mutex
+	silences map[string][]*models.GettableSilence
+	alerts   map[string][]*models.GettableAlert
+	requests []fakeRequest
+	nextID   int
+	// failing holds the status code of the requests of the failing tenants.
+	failing map[string]int
+	// pendingPolls is the number of times the silences added by a POST are
+	// got as pending before being active, polls counting them down by ID.
+	pendingPolls int
+	polls        map[string]int
+}
+
+// newFakeAlertmanager starts a fake Alertmanager, used as --alertmanager.url
+// for the duration of the test.
+func newFakeAlertmanager(t *testing.T) *fakeAlertmanager {
+	t.Helper()
+	am := &fakeAlertmanager{
+		silences: map[string][]*models.GettableSilence{},
+		alerts:   map[string][]*models.GettableAlert{},
+		failing:  map[string]int{},
+		polls:    map[string]int{},
+	}
+	am.Server = httptest.NewServer(http.HandlerFunc(am.serveHTTP))
+	t.Cleanup(am.Close)
+
+	u, err := url.Parse(am.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := alertmanagerURLs
+	alertmanagerURLs = urlListValue{u}
+	t.Cleanup(func() { alertmanagerURLs = old })
+	return am
+}
+
+// addSilence adds an active silence for the tenant, and returns its ID.
+func (am *fakeAlertmanager) addSilence(tenant string, sil models.Silence) string {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+	return am.storeSilence(tenant, sil)
+}
+
+func (am *fakeAlertmanager) storeSilence(tenant string, sil models.Silence) string {
+	am.nextID++
+	id := fmt.Sprintf("00000000-0000-0000-0000-%012d", am.nextID)
+	state := models.SilenceStatusStateActive
+	updatedAt := strfmt.DateTime(time.Now())
+	am.silences[tenant] = append(am.silences[tenant], &models.GettableSilence{
+		ID:        &id,
+		Status:    &models.SilenceStatus{State: &state},
+		UpdatedAt: &updatedAt,
+		Silence:   sil,
+	})
+	return id
+}
+
+// addAlert adds a firing alert with the labels for the tenant.
+func (am *fakeAlertmanager) addAlert(tenant string, ls map[string]string) {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+	var (
+		fingerprint = fmt.Sprintf("%016x", len(am.alerts[tenant])+1)
+		now         = strfmt.DateTime(time.Now().UTC())
+		state       = models.AlertStatusStateActive
+		receiver    = "default"
+	)
+	am.alerts[tenant] = append(am.alerts[tenant], &models.GettableAlert{
+		Alert:       models.Alert{Labels: ls},
+		Annotations: models.LabelSet{},
+		Fingerprint: &fingerprint,
+		StartsAt:    &now,
+		EndsAt:      &now,
+		UpdatedAt:   &now,
+		Receivers:   []*models.Receiver{{Name: &receiver}},
+		Status:      &models.AlertStatus{State: &state, SilencedBy: []string{}, InhibitedBy: []string{}},
+	})
+}
+
+// fail makes the requests of the tenant fail with the status code.
+func (am *fakeAlertmanager) fail(tenant string, code int) {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+	am.failing[tenant] = code
+}
+
+// silence returns the silence of the tenant with the ID, if any.
+func (am *fakeAlertmanager) silence(tenant, id string) *models.GettableSilence {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+	return am.findSilence(tenant, id)
+}
+
+func (am *fakeAlertmanager) findSilence(tenant, id string) *models.GettableSilence {
+	for _, s := range am.silences[tenant] {
+		if *s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+// received returns the requests received so far.
+func (am *fakeAlertmanager) received() []fakeRequest {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+	return append([]fakeRequest(nil), am.requests...)
+}
+
+func (am *fakeAlertmanager) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	am.mtx.Lock()
+	defer am.mtx.Unlock()
+
+	tenant := r.Header.Get("X-Scope-OrgID")
+	am.requests = append(am.requests, fakeRequest{method: r.Method, path: r.URL.Path, tenant: tenant, auth: r.Header.Get("Authorization")})
+
+	w.Header().Set("Content-Type", "application/json")
+	if code, ok := am.failing[tenant]; ok {
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(http.StatusText(code))
+		return
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/silences":
+		silences := am.silences[tenant]
+		if silences == nil {
+			silences = []*models.GettableSilence{}
+		}
+		json.NewEncoder(w).Encode(silences)
+
+	case r.Method == http.MethodPost && r.URL.Path == "/api/v2/silences":
+		var ps models.PostableSilence
+		if err := json.NewDecoder(r.Body).Decode(&ps); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(err.Error())
+			return
+		}
+		id := ps.ID
+		if s := am.findSilence(tenant, id); s != nil {
+			s.Silence = ps.Silence
+		} else {
+			id = am.storeSilence(tenant, ps.Silence)
+			if am.pendingPolls > 0 {
+				pending := models.SilenceStatusStatePending
+				am.findSilence(tenant, id).Status.State = &pending
+				am.polls[id] = am.pendingPolls
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]string{"silenceID": id})
+
+	case strings.HasPrefix(r.URL.Path, "/api/v2/silence/"):
+		id := strings.TrimPrefix(r.URL.Path, "/api/v2/silence/")
+		s := am.findSilence(tenant, id)
+		if s == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(s)
+			if am.polls[id] > 0 {
+				if am.polls[id]--; am.polls[id] == 0 {
+					active := models.SilenceStatusStateActive
+					s.Status.State = &active
+				}
+			}
+		case http.MethodDelete:
+			state := models.SilenceStatusStateExpired
+			s.Status.State = &state
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/alerts":
+		alerts := am.alerts[tenant]
+		if alerts == nil {
+			alerts = []*models.GettableAlert{}
+		}
+		json.NewEncoder(w).Encode(alerts)
+
+	case r.Method == http.MethodGet && r.URL.Path == "/api/v2/status":
+		var (
+			original = "route:\n  receiver: default\nreceivers:\n- name: default\n"
+			status   = "ready"
+			version  = "0.27.0"
+			uptime   = strfmt.DateTime(time.Now().UTC())
+		)
+		json.NewEncoder(w).Encode(&models.AlertmanagerStatus{
+			Cluster:     &models.ClusterStatus{Status: &status, Peers: []*models.PeerStatus{}},
+			Config:      &models.AlertmanagerConfig{Original: &original},
+			Uptime:      &uptime,
+			VersionInfo: &models.VersionInfo{Version: &version},
+		})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// newTestSilence returns a silence of the matchers, like 'alertname=foo',
+// lasting the duration from now.
+func newTestSilence(t *testing.T, d time.Duration, matchers ...string) models.Silence {
+	t.Helper()
+	lms, err := parseMatchers(matchers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		startsAt  = strfmt.DateTime(time.Now().UTC())
+		endsAt    = strfmt.DateTime(time.Now().UTC().Add(d))
+		createdBy = "tester"
+		comment   = "test silence"
+	)
+	return models.Silence{
+		Matchers:  TypeMatchers(lms),
+		StartsAt:  &startsAt,
+		EndsAt:    &endsAt,
+		CreatedBy: &createdBy,
+		Comment:   &comment,
+	}
+}
+
+// testTenantFlags returns the tenant flags with their defaults.
+func testTenantFlags() tenantFlags {
+	return tenantFlags{tenantHTTPHeader: "X-Scope-OrgID", tenantDelimiter: "|"}
+}
+
+// lockedBuffer is a buffer safe for concurrent writes.
+type lockedBuffer struct {
+	mtx sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.buf.String()
+}
+
+// captureLogs writes the logs to the returned buffer for the duration of the
+// test.
+func captureLogs(t *testing.T) *lockedBuffer {
+	t.Helper()
+	buf := &lockedBuffer{}
+	old := logger
+	logger = newLogger(buf, "info", "logfmt")
+	t.Cleanup(func() { logger = old })
+	return buf
+}
+
+// testHTTPConfig returns the HTTP config of the flags.
+func testHTTPConfig(t *testing.T) *promconfig.HTTPClientConfig {
+	t.Helper()
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return httpConfig
+}
+
+// newTestAMClient returns the client of the Alertmanager at u, with the HTTP
+// config of the flags.
+func newTestAMClient(t *testing.T, u *url.URL) *client.AlertmanagerAPI {
+	t.Helper()
+	amclient, err := NewAlertmanagerClient(u, *testHTTPConfig(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return amclient
+}
+
+// testClient returns the client of the tenant, with the HTTP config of the
+// flags.
+func testClient(t *testing.T, tf *tenantFlags, tenant string) *client.AlertmanagerAPI {
+	t.Helper()
+	amclient, err := tf.newClient(testHTTPConfig(t), tenant)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return amclient
+}
+
+// runCommand runs atm with the arguments, and returns what it wrote to stdout
+// and stderr. The Alertmanager URL of the test is given first, no config
+// file is read.
+func runCommand(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	return run(t, false, args)
+}
+
+// runWithConfig runs atm like runCommand, reading the config files first
+// like Execute does.
+func runWithConfig(t *testing.T, args ...string) (string, string, error) {
+	t.Helper()
+	return run(t, true, args)
+}
+
+func run(t *testing.T, withConfig bool, args []string) (string, string, error) {
+	t.Helper()
+	dir := t.TempDir()
+	outFile, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errFile, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldStdout, oldStderr, oldOut, oldLogger, oldURLs := os.Stdout, os.Stderr, stdout, logger, alertmanagerURLs
+	os.Stdout, os.Stderr, stdout = outFile, errFile, outFile
+	for _, f := range format.Formatters {
+		f.SetOutput(outFile)
+	}
+	var urlArgs []string
+	for _, u := range alertmanagerURLs {
+		urlArgs = append(urlArgs, "--alertmanager.url="+u.String())
+	}
+	resetFlags()
+	alertmanagerURLs, failures = nil, nil
+	defer func() {
+		os.Stdout, os.Stderr, stdout, logger, alertmanagerURLs = oldStdout, oldStderr, oldOut, oldLogger, oldURLs
+		for _, f := range format.Formatters {
+			f.SetOutput(oldStdout)
+		}
+		resetFlags()
+		failures = nil
+	}()
+
+	app := newApp()
+	app.Terminate(nil)
+	args = append(urlArgs, args...)
+	if withConfig {
+		err = bindConfig(app, args)
+	}
+	if err == nil {
+		_, err = app.Parse(args)
+	}
+
+	outFile.Close()
+	errFile.Close()
+	out, rerr := os.ReadFile(outFile.Name())
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	diag, rerr := os.ReadFile(errFile.Name())
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	return string(out), string(diag), err
+}
+
+// bindConfig sets the defaults of the flags from the config files, as
+// Execute does.
+func bindConfig(app *kingpin.Application, args []string) error {
+	files, err := selectedConfigFiles(app, args)
+	if err != nil {
+		return err
+	}
+	resolver, err := newConfigResolver(files, legacyFlags)
+	if err != nil {
+		return err
+	}
+	return resolver.bind(app, args)
+}
+
+// resetFlags resets the global flags without default, kingpin only setting
+// the flags given on the command line and the defaults.
+func resetFlags() {
+	configFile, outputTemplate, outputFile, skippedTenantsFile = "", "", "", ""
+	httpConfigFile, httpConfigDir = "", ""
+	tlsCertFile, tlsKeyFile, tlsCAFile, tlsServerName = "", "", "", ""
+	bearerToken, bearerTokenFile = "", ""
+	oauth2ClientID, oauth2Secret, oauth2TokenURL, oauth2Scopes = "", "", "", ""
+	noConfig, quiet, verbose, verboseShowSecrets, httpTrace, appendOutput, tlsInsecure = false, false, false, false, false, false, false
+	colored = false
+	rps, headers = 0, nil
+	configTenants, configSources, configFilesRead = nil, nil, nil
+	requestLimiter, requestLimiterOnce = nil, sync.Once{}
+	transportsMtx.Lock()
+	transports = map[transportKey]runtime.ClientTransport{}
+	transportsMtx.Unlock()
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/alecthomas/kingpin/v2"
+)
+
+var (
+	logLevel  string
+	logFormat string
+	quiet     bool
+
+	// logger writes the diagnostics to stderr, the results of the commands
+	// are written to stdout.
+	logger = newLogger(os.Stderr, "info", "logfmt")
+)
+
+// setupLogger configures the logger from the log flags. With --quiet, only
+// the warnings and errors are logged.
+func setupLogger(_ *kingpin.ParseContext) error {
+	level := logLevel
+	if quiet && (level == "debug" || level == "info") {
+		level = "warn"
+	}
+	logger = newLogger(stderrWriter{}, level, logFormat)
+	return nil
+}
+
+func newLogger(w io.Writer, level, format string) *slog.Logger {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(level)); err != nil {
+		l = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: l}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	// The time is left out to keep the output of a command line tool short.
+	opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey && len(groups) == 0 {
+			return slog.Attr{}
+		}
+		return a
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
@@ -0,0 +1,80 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuietJSON(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	id := am.addSilence("tenant-a", newTestSilence(t, time.Hour, "alertname=foo"))
+	am.addSilence("tenant-b", newTestSilence(t, time.Hour, "alertname=foo"))
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\ntenant-c\n")
+
+	for _, tc := range []struct {
+		name string
+		args []string
+	}{
+		{name: "silence add", args: []string{"silence", "add", "alertname=bar", "--tenant.file", tenantFile, "--comment", "test", "--author", "tester"}},
+		{name: "silence query", args: []string{"silence", "query", "--all-authors", "--tenant.file", tenantFile}},
+		{name: "silence count", args: []string{"silence", "count", "--tenant.file", tenantFile}},
+		{name: "silence get", args: []string{"silence", "get", id, "--tenant", "tenant-a"}},
+		{name: "silence expire", args: []string{"silence", "expire", id, "--tenant", "tenant-a"}},
+		{name: "alert list", args: []string{"alert", "list", "--tenant.file", tenantFile}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, stderr, err := runCommand(t, append([]string{"--quiet", "--output=json"}, tc.args...)...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// stdout holds a single JSON document, nothing else.
+			dec := json.NewDecoder(strings.NewReader(stdout))
+			var doc interface{}
+			if err := dec.Decode(&doc); err != nil {
+				t.Fatalf("expected JSON on stdout, got %q (%v)", stdout, err)
+			}
+			if dec.More() {
+				t.Fatalf("expected a single JSON document on stdout, got %q", stdout)
+			}
+			if stderr != "" {
+				t.Fatalf("expected nothing on stderr, got %q", stderr)
+			}
+		})
+	}
+}
+
+func TestQuietErrors(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	am.fail("tenant-b", http.StatusInternalServerError)
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\ntenant-c\n")
+
+	// The summary is left out, the errors are still logged.
+	args := []string{"silence", "query", "--all-authors", "--tenant.file", tenantFile}
+	_, stderr, err := runCommand(t, args...)
+	if err == nil || !strings.Contains(stderr, "tenant-b") || !strings.Contains(stderr, "failed 1") {
+		t.Fatalf("expected the error and the summary on stderr, got %q (%v)", stderr, err)
+	}
+	_, stderr, err = runCommand(t, append([]string{"--quiet"}, args...)...)
+	if err == nil || !strings.Contains(stderr, "tenant-b") {
+		t.Fatalf("expected the error of tenant-b on stderr, got %q (%v)", stderr, err)
+	}
+	if strings.Contains(stderr, "failed 1") {
+		t.Fatalf("expected no summary with --quiet, got %q", stderr)
+	}
+}
@@ -0,0 +1,375 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"golang.org/x/term"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/cli/format"
+)
+
+var (
+	outputFile     string
+	appendOutput   bool
+	outputTemplate string
+	outputTmpl     *template.Template
+
+	// stdout receives the results of the commands. They are buffered with
+	// --output-file, and written to the file once the command is done.
+	stdout    io.Writer = os.Stdout
+	outputBuf bytes.Buffer
+
+	// colorMode is --color, and colored is set when the states of the
+	// silences are colorized in the human-readable outputs.
+	colorMode string
+	colored   bool
+)
+
+// silenceResult is the machine-readable outcome of an operation on a silence.
+type silenceResult struct {
+	Tenant    string `json:"tenant,omitempty"`
+	Line      int    `json:"line,omitempty"`
+	Source    string `json:"source,omitempty"`
+	SilenceID string `json:"silenceID,omitempty"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+
+	// StartsAt and EndsAt are set for the added silences.
+	StartsAt *time.Time `json:"startsAt,omitempty"`
+	EndsAt   *time.Time `json:"endsAt,omitempty"`
+
+	Silence *models.PostableSilence `json:"silence,omitempty"`
+}
+
+// newSilenceResults converts the tenant results, setting the given status
+// on the successful ones.
+func newSilenceResults(results []tenantResult, status string) []silenceResult {
+	out := make([]silenceResult, 0, len(results))
+	for _, r := range results {
+		sr := silenceResult{Tenant: r.tenant, SilenceID: r.id, Status: status}
+		if r.err != nil {
+			sr.Status = "failed"
+			sr.Error = r.err.Error()
+		}
+		out = append(out, sr)
+	}
+	return out
+}
+
+// printJSON writes v as JSON to stdout.
+func printJSON(v interface{}) error {
+	return json.NewEncoder(stdout).Encode(v)
+}
+
+// structuredOutput reports whether the output is machine-readable, JSON or
+// YAML.
+func structuredOutput() bool {
+	return output == "json" || output == "yaml"
+}
+
+// printStructured writes v as YAML with --output=yaml, and as JSON otherwise.
+func printStructured(v interface{}) error {
+	if output == "yaml" {
+		return writeYAML(stdout, v)
+	}
+	return printJSON(v)
+}
+
+// writeYAML writes v as YAML, with the field names of its JSON encoding as
+// the API models only have JSON tags.
+func writeYAML(w io.Writer, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	if b, err = yaml.Marshal(doc); err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+func init() {
+	format.Formatters["yaml"] = &yamlFormatter{writer: os.Stdout}
+}
+
+// yamlFormatter is the formatter of --output=yaml, for the commands writing
+// their results with the Alertmanager formatters.
+type yamlFormatter struct {
+	writer io.Writer
+}
+
+func (f *yamlFormatter) SetOutput(w io.Writer) { f.writer = w }
+
+func (f *yamlFormatter) FormatSilences(silences []models.GettableSilence) error {
+	if silences == nil {
+		silences = []models.GettableSilence{}
+	}
+	return writeYAML(f.writer, silences)
+}
+
+func (f *yamlFormatter) FormatAlerts(alerts []*models.GettableAlert) error {
+	if alerts == nil {
+		alerts = []*models.GettableAlert{}
+	}
+	return writeYAML(f.writer, alerts)
+}
+
+func (f *yamlFormatter) FormatConfig(status *models.AlertmanagerStatus) error {
+	return writeYAML(f.writer, status)
+}
+
+func (f *yamlFormatter) FormatClusterStatus(status *models.ClusterStatus) error {
+	return writeYAML(f.writer, status)
+}
+
+// printf writes human-readable output, which is omitted with --output=json,
+// --output=yaml and --output=template.
+func printf(format string, a ...interface{}) {
+	if structuredOutput() || output == "template" {
+		return
+	}
+	fmt.Fprintf(stdout, format, a...)
+}
+
+// printResult writes the human-readable result of an operation on a silence,
+// or only the silence ID with --quiet.
+func printResult(id, format string, a ...interface{}) {
+	if quiet {
+		printf("%s\n", id)
+		return
+	}
+	printf(format, a...)
+}
+
+// printResults writes the results with --output=json, --output=yaml and
+// --output=template, the other outputs are written by printf as the command
+// goes.
+func printResults(results []silenceResult) error {
+	switch output {
+	case "json", "yaml":
+		return printStructured(results)
+	case "template":
+		for _, r := range results {
+			if err := executeTemplate(r); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tableField is a column of the table output, selected by --fields. The
+// values of the colorized fields go through colorState.
+type tableField struct {
+	name      string
+	header    string
+	colorized bool
+}
+
+// parseFields returns the comma separated fields selected by --fields in
+// their order, the default ones when empty. The tenant field is added first
+// to the default ones when withTenant is set.
+func parseFields(fields string, known []tableField, defaults []string, withTenant bool) ([]tableField, error) {
+	names := defaults
+	if fields != "" {
+		names = strings.Split(fields, ",")
+	} else if withTenant {
+		names = append([]string{"tenant"}, defaults...)
+	}
+
+	byName := make(map[string]tableField, len(known))
+	for _, f := range known {
+		byName[f.name] = f
+	}
+	selected := make([]tableField, 0, len(names))
+	for _, name := range names {
+		f, ok := byName[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown field '%s', valid fields are: %s", strings.TrimSpace(name), fieldNames(known))
+		}
+		selected = append(selected, f)
+	}
+	return selected, nil
+}
+
+// hasField reports whether the field is one of fields.
+func hasField(fields []tableField, name string) bool {
+	for _, f := range fields {
+		if f.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldNames returns the comma separated names of the fields.
+func fieldNames(fields []tableField) string {
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, f.name)
+	}
+	return strings.Join(names, ",")
+}
+
+// writeTableHeader writes the headers of the fields as a row of the table.
+func writeTableHeader(w io.Writer, fields []tableField) {
+	for _, f := range fields {
+		header := f.header
+		if colored && f.colorized {
+			// The tabwriter counts the color codes of the values, the header
+			// gets codes of the same length to stay aligned with them.
+			header = "\033[39m" + header + "\033[0m"
+		}
+		fmt.Fprintf(w, "%s\t", header)
+	}
+	fmt.Fprintln(w)
+}
+
+// stateColors are the colors of the silence states. The codes have the same
+// length so that the colorized columns stay aligned.
+var stateColors = map[string]string{
+	models.SilenceStatusStateActive:  "\033[32m",
+	models.SilenceStatusStatePending: "\033[33m",
+	models.SilenceStatusStateExpired: "\033[90m",
+}
+
+// setupColor enables the colors with --color=always, or with --color=auto
+// when stdout is a terminal and NO_COLOR isn't set.
+func setupColor(_ *kingpin.ParseContext) error {
+	switch colorMode {
+	case "always":
+		colored = true
+	case "auto":
+		colored = os.Getenv("NO_COLOR") == "" && outputFile == "" && term.IsTerminal(int(os.Stdout.Fd()))
+	}
+	return nil
+}
+
+// colorState returns the silence state, colorized when the colors are
+// enabled: active in green, pending in yellow and expired dimmed.
+func colorState(state string) string {
+	c, ok := stateColors[state]
+	if !colored || !ok {
+		return state
+	}
+	return c + state + "\033[0m"
+}
+
+// silenceTemplateData is the template context of a listed silence, with the
+// same fields as silenceResult.
+type silenceTemplateData struct {
+	Tenant    string
+	SilenceID string
+	Status    string
+	Silence   *models.GettableSilence
+	// Meta holds the metadata of the silence comment, added by --meta.
+	Meta map[string]string
+}
+
+// setupTemplate parses --template, so that an invalid template fails before
+// any request.
+func setupTemplate(_ *kingpin.ParseContext) error {
+	if output != "template" {
+		return nil
+	}
+	if outputTemplate == "" {
+		return errors.New("--template is required with --output=template")
+	}
+	var err error
+	funcs := template.FuncMap{"matchers": formatMatchers}
+	outputTmpl, err = template.New("output").Funcs(funcs).Parse(outputTemplate)
+	if err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	return nil
+}
+
+// executeTemplate writes data with the output template, followed by a newline.
+func executeTemplate(data interface{}) error {
+	if err := outputTmpl.Execute(stdout, data); err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+	_, err := fmt.Fprintln(stdout)
+	return err
+}
+
+// setupOutputFile buffers the results when they are written to a file.
+func setupOutputFile(_ *kingpin.ParseContext) error {
+	if outputFile == "" {
+		return nil
+	}
+	stdout = &outputBuf
+	for _, f := range format.Formatters {
+		f.SetOutput(stdout)
+	}
+	return nil
+}
+
+// writeOutputFile writes the buffered results to the output file, after its
+// content with --append. A temporary file is renamed over the output file so
+// that it is never left half written.
+func writeOutputFile() error {
+	if outputFile == "" {
+		return nil
+	}
+
+	var b []byte
+	mode := os.FileMode(0o644)
+	if fi, err := os.Stat(outputFile); err == nil {
+		mode = fi.Mode().Perm()
+		if appendOutput {
+			if b, err = os.ReadFile(outputFile); err != nil {
+				return err
+			}
+		}
+	}
+	b = append(b, outputBuf.Bytes()...)
+
+	tmp, err := os.CreateTemp(filepath.Dir(outputFile), "."+filepath.Base(outputFile)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), outputFile)
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestYAMLOutput(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	id := am.addSilence("tenant-a", newTestSilence(t, time.Hour, "alertname=foo"))
+	am.addSilence("tenant-b", newTestSilence(t, time.Hour, "alertname=bar"))
+	am.addAlert("tenant-a", map[string]string{"alertname": "foo"})
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\n")
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		expected []string
+	}{
+		{name: "silence query", args: []string{"silence", "query", "--all-authors", "--tenant", "tenant-a"}, expected: []string{"createdBy: tester", "value: foo"}},
+		{name: "silence query tenants", args: []string{"silence", "query", "--all-authors", "--tenant.file", tenantFile}, expected: []string{"tenant: tenant-a", "tenant: tenant-b", "value: bar"}},
+		{name: "silence get", args: []string{"silence", "get", id, "--tenant", "tenant-a"}, expected: []string{"id: " + id}},
+		{name: "silence count", args: []string{"silence", "count", "--tenant.file", tenantFile}, expected: []string{"tenant: tenant-b", "count: 1"}},
+		{name: "alert list", args: []string{"alert", "list", "--tenant", "tenant-a"}, expected: []string{"alertname: foo", "fingerprint:"}},
+		{name: "alert list tenants", args: []string{"alert", "list", "--tenant.file", tenantFile}, expected: []string{"tenant: tenant-a", "alertname: foo"}},
+		{name: "status", args: []string{"status", "--tenant", "tenant-a"}, expected: []string{"version: 0.27.0", "status: ready"}},
+		{name: "status tenants", args: []string{"status", "--tenant.file", tenantFile}, expected: []string{"tenant: tenant-b", "version: 0.27.0"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, _, err := runCommand(t, append([]string{"--output=yaml"}, tc.args...)...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var doc interface{}
+			if err := yaml.Unmarshal([]byte(stdout), &doc); err != nil || doc == nil {
+				t.Fatalf("expected a YAML document, got %q (%v)", stdout, err)
+			}
+			for _, s := range tc.expected {
+				if !strings.Contains(stdout, s) {
+					t.Fatalf("expected %q in %q", s, stdout)
+				}
+			}
+		})
+	}
+}
+
+func TestYAMLOutputResults(t *testing.T) {
+	newFakeAlertmanager(t)
+
+	stdout, _, err := runCommand(t, "--output=yaml", "silence", "add", "alertname=foo", "--tenant", "tenant-a", "--comment", "test", "--author", "tester")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var results []map[string]string
+	if err := yaml.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("expected a YAML list, got %q (%v)", stdout, err)
+	}
+	if len(results) != 1 || results[0]["status"] != "added" || results[0]["tenant"] != "tenant-a" {
+		t.Fatalf("unexpected results %v", results)
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		fields     string
+		withTenant bool
+		selected   string
+		err        string
+	}{
+		{name: "defaults", selected: "id,matchers,startsAt,endsAt,state,createdBy"},
+		{name: "defaults with tenant", withTenant: true, selected: "tenant,id,matchers,startsAt,endsAt,state,createdBy"},
+		{name: "custom order", fields: "comment,id,endsAt", selected: "comment,id,endsAt"},
+		{name: "custom order with tenant", fields: "id, endsAt", withTenant: true, selected: "id,endsAt"},
+		{name: "typo", fields: "id,endAt", err: "unknown field 'endAt', valid fields are: " + fieldNames(silenceFields)},
+		{name: "empty field", fields: "id,,endsAt", err: "unknown field ''"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			fields, err := parseFields(tc.fields, silenceFields, defaultSilenceFields, tc.withTenant)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if selected := fieldNames(fields); selected != tc.selected {
+				t.Fatalf("expected the fields %s, got %s", tc.selected, selected)
+			}
+		})
+	}
+}
+
+func TestFieldsOutput(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	id := am.addSilence("tenant-a", newTestSilence(t, time.Hour, "alertname=foo"))
+	am.addAlert("tenant-a", map[string]string{"alertname": "foo", "severity": "critical"})
+
+	stdout, _, err := runCommand(t, "silence", "query", "--all-authors", "--tenant", "tenant-a", "--fields", "comment,id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 || strings.Join(strings.Fields(lines[0]), " ") != "Comment ID" || strings.Join(strings.Fields(lines[1]), " ") != "test silence "+id {
+		t.Fatalf("expected the comment and ID columns, got %q", stdout)
+	}
+
+	stdout, _, err = runCommand(t, "alert", "list", "--tenant", "tenant-a", "--fields", "labels")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(stdout), "\n"); len(lines) != 2 || !strings.Contains(lines[1], "severity") {
+		t.Fatalf("expected the labels column, got %q", stdout)
+	}
+
+	_, _, err = runCommand(t, "silence", "query", "--all-authors", "--tenant", "tenant-a", "--fields", "id,author")
+	if err == nil || !strings.Contains(err.Error(), "unknown field 'author', valid fields are: tenant,id,matchers") {
+		t.Fatalf("expected an unknown field error, got %v", err)
+	}
+}
+
+func TestColorOutput(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	am.addSilence("tenant-a", newTestSilence(t, time.Hour, "alertname=foo"))
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\n")
+
+	for _, tc := range []struct {
+		name    string
+		env     string
+		args    []string
+		colored bool
+	}{
+		{name: "auto when piped", args: []string{"silence", "query"}},
+		{name: "auto with NO_COLOR", env: "1", args: []string{"silence", "query"}},
+		{name: "never", args: []string{"--color=never", "silence", "query"}},
+		{name: "always", args: []string{"--color=always", "silence", "query"}, colored: true},
+		{name: "always with NO_COLOR", env: "1", args: []string{"--color=always", "silence", "query"}, colored: true},
+		{name: "always as JSON", args: []string{"--color=always", "--output=json", "silence", "query"}},
+		{name: "always as YAML", args: []string{"--color=always", "--output=yaml", "silence", "query"}},
+		{name: "always as CSV", args: []string{"--color=always", "--output=csv", "silence", "query"}},
+		{name: "always as JSON of the tenants", args: []string{"--color=always", "--output=json", "silence", "query", "--tenant.file", tenantFile}},
+		{name: "always with a template", args: []string{"--color=always", "--output=template", "--template={{.SilenceID}}", "silence", "query"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("NO_COLOR", tc.env)
+			args := append(tc.args, "--all-authors")
+			if !strings.Contains(strings.Join(args, " "), "--tenant.file") {
+				args = append(args, "--tenant", "tenant-a")
+			}
+			stdout, _, err := runCommand(t, args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !strings.Contains(stdout, "active") && !strings.Contains(stdout, "0000") {
+				t.Fatalf("expected the silence, got %q", stdout)
+			}
+			if colored := strings.Contains(stdout, "\033["); colored != tc.colored {
+				t.Fatalf("expected colored %t, got %q", tc.colored, stdout)
+			}
+		})
+	}
+}
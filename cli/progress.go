@@ -0,0 +1,120 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// showProgress is unset by --no-progress to never draw the progress bar.
+var showProgress bool
+
+const (
+	progressWidth = 30
+	// progressRefresh bounds how often the progress bar is redrawn.
+	progressRefresh = 100 * time.Millisecond
+)
+
+var (
+	// progressMtx guards the drawing of activeProgress, the progress bar
+	// being drawn on stderr, if any.
+	progressMtx    sync.Mutex
+	activeProgress *progress
+)
+
+// progress is a progress bar drawn on stderr, counting the tenants done out
+// of the total with the estimated time left.
+type progress struct {
+	total int
+	done  int
+	start time.Time
+	drawn time.Time
+}
+
+// newProgress starts a progress bar for total tenants. It returns nil, which
+// draws nothing, with --no-progress or --quiet, for a single tenant, or when
+// stdout or stderr is not a terminal.
+func newProgress(total int) *progress {
+	if !showProgress || quiet || total < 2 ||
+		!term.IsTerminal(int(os.Stdout.Fd())) || !term.IsTerminal(int(os.Stderr.Fd())) {
+		return nil
+	}
+	p := &progress{total: total, start: time.Now()}
+	progressMtx.Lock()
+	defer progressMtx.Unlock()
+	activeProgress = p
+	p.draw()
+	return p
+}
+
+// inc counts a tenant as done. It's safe to call from the workers.
+func (p *progress) inc() {
+	if p == nil {
+		return
+	}
+	progressMtx.Lock()
+	defer progressMtx.Unlock()
+	p.done++
+	if p.done == p.total || time.Since(p.drawn) >= progressRefresh {
+		p.draw()
+	}
+}
+
+// finish erases the progress bar.
+func (p *progress) finish() {
+	if p == nil {
+		return
+	}
+	progressMtx.Lock()
+	defer progressMtx.Unlock()
+	activeProgress = nil
+	fmt.Fprint(os.Stderr, "\r\033[K")
+}
+
+// draw writes the progress bar over the current line of stderr, with
+// progressMtx held.
+func (p *progress) draw() {
+	p.drawn = time.Now()
+	filled := progressWidth * p.done / p.total
+	eta := "?"
+	if p.done > 0 {
+		elapsed := time.Since(p.start)
+		left := elapsed * time.Duration(p.total-p.done) / time.Duration(p.done)
+		eta = left.Round(time.Second).String()
+	}
+	fmt.Fprintf(os.Stderr, "\r\033[K[%s%s] %d/%d ETA %s",
+		strings.Repeat("=", filled), strings.Repeat(" ", progressWidth-filled), p.done, p.total, eta)
+}
+
+// stderrWriter writes the logs to stderr, erasing the progress bar before
+// and drawing it again after, so that the log lines are kept clean.
+type stderrWriter struct{}
+
+func (stderrWriter) Write(b []byte) (int, error) {
+	progressMtx.Lock()
+	defer progressMtx.Unlock()
+	if activeProgress == nil {
+		return os.Stderr.Write(b)
+	}
+	fmt.Fprint(os.Stderr, "\r\033[K")
+	n, err := os.Stderr.Write(b)
+	activeProgress.draw()
+	return n, err
+}
@@ -0,0 +1,130 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-openapi/runtime"
+)
+
+// rateLimitRetries is the minimum number of retries of the rate limited
+// requests (429), so that the Retry-After header is honored even without
+// --retries.
+const rateLimitRetries = 5
+
+// validateRetryFlags rejects the negative --retries and --retry-backoff, the
+// backoff jitter being drawn from a positive range.
+func validateRetryFlags(_ *kingpin.ParseContext) error {
+	if retries < 0 {
+		return fmt.Errorf("invalid --retries %d, it must not be negative", retries)
+	}
+	if retryBackoff < 0 {
+		return fmt.Errorf("invalid --retry-backoff %s, it must not be negative", retryBackoff)
+	}
+	return nil
+}
+
+// withRetry calls fn until it succeeds, fails with an error which can't be
+// retried, the retries are exhausted or the context is done. The action
+// describes the call in the retry messages.
+//
+// Rate limited requests (429) are retried after the delay given by the
+// Retry-After header, at most --retries times and at least rateLimitRetries
+// times.
+func withRetry(ctx context.Context, action string, fn func() error) error {
+	backoff := retryBackoff
+	for attempt, rateLimited := 1, 1; ; {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		var wait time.Duration
+		if d, ok := retryAfter(err); ok && ctx.Err() == nil {
+			if rateLimited > max(retries, rateLimitRetries) {
+				return err
+			}
+			wait = d
+			logger.Warn("Unable to "+action+", rate limited, retrying", "wait", wait.String(), "attempt", rateLimited, "err", err)
+			rateLimited++
+		} else {
+			if attempt > retries || !isRetryable(ctx, err) {
+				return err
+			}
+			// Exponential backoff with jitter between backoff/2 and backoff.
+			wait = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+			logger.Warn("Unable to "+action+", retrying", "wait", wait.Round(time.Millisecond).String(), "attempt", attempt, "retries", retries, "err", err)
+			attempt++
+			backoff *= 2
+		}
+
+		// Don't wait beyond the deadline, the request couldn't be sent anyway.
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// retryAfter returns the delay requested by the Retry-After header of a
+// rate limited (429) response.
+func retryAfter(err error) (time.Duration, bool) {
+	var apiErr *runtime.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != http.StatusTooManyRequests {
+		return 0, false
+	}
+	resp, ok := apiErr.Response.(runtime.ClientResponse)
+	if !ok {
+		return 0, false
+	}
+
+	// Without a valid header, or with a delay already elapsed, wait for the
+	// retry backoff rather than retrying right away.
+	header := resp.GetHeader("Retry-After")
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d, true
+		}
+	}
+	return retryBackoff, true
+}
+
+// isRetryable reports whether the error is transient: either a server error
+// or a network error. Client errors, like validation ones, aren't retried.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var status runtime.ClientResponseStatus
+	if errors.As(err, &status) {
+		return status.IsServerError()
+	}
+	return true
+}
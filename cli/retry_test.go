@@ -0,0 +1,178 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+	promconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+)
+
+// setRetries sets --retries and --retry-backoff for the test.
+func setRetries(t *testing.T, n int, backoff time.Duration) {
+	t.Helper()
+	oldRetries, oldBackoff := retries, retryBackoff
+	retries, retryBackoff = n, backoff
+	t.Cleanup(func() { retries, retryBackoff = oldRetries, oldBackoff })
+}
+
+// newTestClient returns an Alertmanager client of the test server.
+func newTestClient(t *testing.T, srv *httptest.Server) *client.AlertmanagerAPI {
+	t.Helper()
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr, err := newClientRuntime(u, promconfig.HTTPClientConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client.New(cr, strfmt.Default)
+}
+
+// rateLimitedServer returns a server answering the first limited requests
+// with 429 and the retryAfter header, then with no silence.
+func rateLimitedServer(limited int64, retryAfter string) (*httptest.Server, *int64) {
+	var requests int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if atomic.AddInt64(&requests, 1) <= limited {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	return srv, &requests
+}
+
+func getSilences(ctx context.Context, amclient *client.AlertmanagerAPI) error {
+	return withRetry(ctx, "query silences", func() error {
+		_, err := amclient.Silence.GetSilences(silence.NewGetSilencesParams().WithContext(ctx))
+		return err
+	})
+}
+
+func TestWithRetryRateLimited(t *testing.T) {
+	setRetries(t, 0, 10*time.Millisecond)
+	srv, requests := rateLimitedServer(1, "1")
+	defer srv.Close()
+
+	start := time.Now()
+	if err := getSilences(context.Background(), newTestClient(t, srv)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if *requests != 2 {
+		t.Fatalf("expected 2 requests, got %d", *requests)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("expected to wait for the Retry-After delay of 1s, waited %s", elapsed)
+	}
+}
+
+func TestWithRetryRateLimitedNoDelay(t *testing.T) {
+	// The Retry-After delays already elapsed fall back to the retry backoff
+	// rather than retrying right away.
+	for _, retryAfter := range []string{"0", "-1", "Mon, 02 Jan 2006 15:04:05 GMT", "soon"} {
+		t.Run(retryAfter, func(t *testing.T) {
+			setRetries(t, 0, 50*time.Millisecond)
+			srv, requests := rateLimitedServer(2, retryAfter)
+			defer srv.Close()
+
+			start := time.Now()
+			if err := getSilences(context.Background(), newTestClient(t, srv)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if *requests != 3 {
+				t.Fatalf("expected 3 requests, got %d", *requests)
+			}
+			if elapsed := time.Since(start); elapsed < 2*retryBackoff {
+				t.Fatalf("expected to wait for the retry backoff twice, waited %s", elapsed)
+			}
+		})
+	}
+}
+
+func TestWithRetryRateLimitedRetries(t *testing.T) {
+	for _, tc := range []struct {
+		retries  int
+		requests int64
+	}{
+		{retries: 0, requests: rateLimitRetries + 1},
+		{retries: rateLimitRetries + 2, requests: rateLimitRetries + 3},
+	} {
+		setRetries(t, tc.retries, time.Millisecond)
+		srv, requests := rateLimitedServer(100, "0")
+
+		err := getSilences(context.Background(), newTestClient(t, srv))
+		srv.Close()
+		if err == nil {
+			t.Fatalf("expected an error with %d retries", tc.retries)
+		}
+		if *requests != tc.requests {
+			t.Fatalf("expected %d requests with %d retries, got %d", tc.requests, tc.retries, *requests)
+		}
+	}
+}
+
+func TestWithRetryRateLimitedDeadline(t *testing.T) {
+	setRetries(t, 0, time.Millisecond)
+	srv, requests := rateLimitedServer(1, "60")
+	defer srv.Close()
+
+	// The request is given up rather than waiting beyond the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := getSilences(ctx, newTestClient(t, srv)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if *requests != 1 {
+		t.Fatalf("expected 1 request, got %d", *requests)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected to give up right away, waited %s", elapsed)
+	}
+}
+
+func TestNegativeRetryFlags(t *testing.T) {
+	for _, tc := range []struct {
+		flag string
+		err  string
+	}{
+		{"--retries=-1", "invalid --retries -1, it must not be negative"},
+		{"--retry-backoff=-1s", "invalid --retry-backoff -1s, it must not be negative"},
+	} {
+		t.Run(tc.flag, func(t *testing.T) {
+			am := newFakeAlertmanager(t)
+			_, _, err := runCommand(t, tc.flag, "silence", "query", "--tenant", "tenant-a")
+			if err == nil || err.Error() != tc.err {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+			if n := len(am.received()); n != 0 {
+				t.Fatalf("expected no request to be sent, got %d", n)
+			}
+		})
+	}
+}
@@ -14,9 +14,17 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
@@ -26,17 +34,37 @@ import (
 	"github.com/prometheus/common/version"
 
 	"github.com/prometheus/alertmanager/api/v2/client"
-	"github.com/prometheus/alertmanager/cli/config"
 	"github.com/prometheus/alertmanager/cli/format"
 )
 
 var (
-	alertmanagerURL *url.URL
-	timeout         time.Duration
-	httpConfigFile  string
+	alertmanagerURLs   urlListValue
+	timeout            time.Duration
+	deadline           time.Duration
+	skippedTenantsFile string
+	httpConfigFile     string
+	httpConfigDir      string
+	output             string
+	retries            int
+	retryBackoff       time.Duration
+	rps                float64
+	tlsCertFile        string
+	tlsKeyFile         string
+	tlsCAFile          string
+	tlsServerName      string
+	tlsInsecure        bool
+	bearerToken        string
+	bearerTokenFile    string
+	oauth2ClientID     string
+	oauth2Secret       string
+	oauth2TokenURL     string
+	oauth2Scopes       string
+	headers            []string
 
-	configFiles = []string{os.ExpandEnv("$HOME/.config/atm/config.yml"), "/etc/atm/config.yml"}
-	legacyFlags = map[string]string{"comment_required": "require-comment"}
+	insecureWarning sync.Once
+
+	configFiles = []string{filepath.Join(userConfigDir(), "atm", "config.yml"), "/etc/atm/config.yml"}
+	legacyFlags = map[string]string{"comment_required": "require-comment", "tenant_http_header": "tenant.http-header"}
 )
 
 func requireAlertManagerURL(pc *kingpin.ParseContext) error {
@@ -51,8 +79,16 @@ func requireAlertManagerURL(pc *kingpin.ParseContext) error {
 			return nil
 		}
 	}
-	if alertmanagerURL == nil {
-		kingpin.Fatalf("required flag --alertmanager.url not provided")
+	if len(alertmanagerURLs) == 0 {
+		return errors.New("required flag --alertmanager.url not provided")
+	}
+	for _, u := range alertmanagerURLs {
+		if err := checkAMURL(u); err != nil {
+			return fmt.Errorf("invalid --alertmanager.url: %v", err)
+		}
+		if err := checkBasicAuth(u); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -64,91 +100,404 @@ const (
 )
 
 // NewAlertmanagerClientConfig initializes an alertmanager client config with the given URL.
-func NewAlertmanagerClientConfig() *promconfig.HTTPClientConfig {
+func NewAlertmanagerClientConfig() (*promconfig.HTTPClientConfig, error) {
 	var httpConfig *promconfig.HTTPClientConfig
 	if httpConfigFile != "" {
 		var err error
 		httpConfig, _, err = promconfig.LoadHTTPConfigFile(httpConfigFile)
 		if err != nil {
-			kingpin.Fatalf("failed to load HTTP config file: %v", err)
+			return nil, fmt.Errorf("failed to load HTTP config file: %v", err)
 		}
 	} else {
 		httpConfig = &promconfig.HTTPClientConfig{}
 	}
-	return httpConfig
+
+	if tlsCertFile != "" || tlsKeyFile != "" || tlsCAFile != "" || tlsServerName != "" || tlsInsecure {
+		if httpConfigFile != "" {
+			return nil, errors.New("tls flags and http.config.file are mutually exclusive")
+		}
+		if (tlsCertFile == "") != (tlsKeyFile == "") {
+			return nil, errors.New("tls.cert-file and tls.key-file must be given together")
+		}
+		if tlsInsecure && tlsCAFile != "" {
+			return nil, errors.New("tls.insecure-skip-verify and tls.ca-file are mutually exclusive")
+		}
+		if tlsInsecure {
+			insecureWarning.Do(func() {
+				logger.Warn("The Alertmanager certificate is not verified (--tls.insecure-skip-verify), do not use it in production")
+			})
+		}
+		httpConfig.TLSConfig = promconfig.TLSConfig{
+			CertFile:           tlsCertFile,
+			KeyFile:            tlsKeyFile,
+			CAFile:             tlsCAFile,
+			ServerName:         tlsServerName,
+			InsecureSkipVerify: tlsInsecure,
+		}
+	}
+
+	if bearerToken != "" || bearerTokenFile != "" {
+		if httpConfigFile != "" {
+			return nil, errors.New("bearer token flags and http.config.file are mutually exclusive")
+		}
+		if bearerToken != "" && bearerTokenFile != "" {
+			return nil, errors.New("bearer-token and bearer-token-file are mutually exclusive")
+		}
+		// The credentials file is read on each request, so that a token
+		// rotated on disk is picked up during long runs.
+		httpConfig.Authorization = &promconfig.Authorization{
+			Type:            "Bearer",
+			Credentials:     promconfig.Secret(bearerToken),
+			CredentialsFile: bearerTokenFile,
+		}
+	}
+
+	if oauth2ClientID != "" || oauth2Secret != "" || oauth2TokenURL != "" || oauth2Scopes != "" {
+		if httpConfigFile != "" {
+			return nil, errors.New("oauth2 flags and http.config.file are mutually exclusive")
+		}
+		if httpConfig.Authorization != nil {
+			return nil, errors.New("oauth2 flags and bearer token are mutually exclusive")
+		}
+		if oauth2ClientID == "" || oauth2TokenURL == "" {
+			return nil, errors.New("oauth2.client-id and oauth2.token-url are required for oauth2")
+		}
+		var scopes []string
+		for _, scope := range strings.Split(oauth2Scopes, ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				scopes = append(scopes, scope)
+			}
+		}
+		// The token is fetched once per client and refreshed when it expires.
+		httpConfig.OAuth2 = &promconfig.OAuth2{
+			ClientID:     oauth2ClientID,
+			ClientSecret: promconfig.Secret(oauth2Secret),
+			TokenURL:     oauth2TokenURL,
+			Scopes:       scopes,
+		}
+	}
+	if err := addHTTPHeaders(httpConfig); err != nil {
+		return nil, err
+	}
+	return httpConfig, nil
 }
 
-// NewAlertmanagerClient initializes an alertmanager client with the given URL.
-func NewAlertmanagerClient(amURL *url.URL, httpConfig promconfig.HTTPClientConfig) *client.AlertmanagerAPI {
-	address := defaultAmHost + ":" + defaultAmPort
-	schemes := []string{"http"}
+// addHTTPHeaders adds the --header headers to the HTTP config, after the
+// ones it already has. The headers are copied first, so the headers of
+// another config are never changed.
+func addHTTPHeaders(httpConfig *promconfig.HTTPClientConfig) error {
+	if len(headers) == 0 {
+		return nil
+	}
+	httpConfig.HTTPHeaders = cloneHeaders(httpConfig.HTTPHeaders)
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" {
+			return fmt.Errorf("invalid header '%s', expected 'Name: Value'", h)
+		}
+		header := httpConfig.HTTPHeaders.Headers[name]
+		header.Values = append(header.Values, value)
+		httpConfig.HTTPHeaders.Headers[name] = header
+	}
+	return nil
+}
+
+// cloneHeaders returns a copy of the headers, sharing none of their values.
+func cloneHeaders(h *promconfig.Headers) *promconfig.Headers {
+	out := &promconfig.Headers{Headers: map[string]promconfig.Header{}}
+	if h == nil {
+		return out
+	}
+	for name, header := range h.Headers {
+		out.Headers[name] = promconfig.Header{
+			Values:  append([]string(nil), header.Values...),
+			Secrets: append([]promconfig.Secret(nil), header.Secrets...),
+			Files:   append([]string(nil), header.Files...),
+		}
+	}
+	return out
+}
+
+// userConfigDir returns $XDG_CONFIG_HOME, or $HOME/.config when it's not set.
+func userConfigDir() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(os.Getenv("HOME"), ".config")
+}
+
+// amURLEnvar returns the environment variable giving the default Alertmanager
+// URL, ATM_ALERTMANAGER_URL when it's set and ALERTMANAGER_URL otherwise.
+func amURLEnvar() string {
+	if _, ok := os.LookupEnv("ATM_ALERTMANAGER_URL"); ok {
+		return "ATM_ALERTMANAGER_URL"
+	}
+	return "ALERTMANAGER_URL"
+}
+
+// checkAMURL returns an error when the Alertmanager URL has no host or a
+// scheme other than http and https, and warns when it has no scheme.
+func checkAMURL(amURL *url.URL) error {
+	u := *amURL
+	switch {
+	case u.Opaque != "":
+		// 'host:port' is parsed as the 'host' scheme with an opaque 'port'.
+		port, _, _ := strings.Cut(u.Opaque, "/")
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return fmt.Errorf("invalid port '%s' in '%s'", port, u.Redacted())
+		}
+	case u.Scheme == "":
+		if u.Host == "" && (u.Path == "" || strings.HasPrefix(u.Path, "/")) {
+			return fmt.Errorf("no host in '%s'", u.Redacted())
+		}
+	case u.Scheme != "http" && u.Scheme != "https":
+		return fmt.Errorf("unsupported scheme '%s' in '%s', expected http or https", u.Scheme, u.Redacted())
+	case u.Hostname() == "":
+		return fmt.Errorf("no host in '%s'", u.Redacted())
+	default:
+		return nil
+	}
+	logger.Warn("No scheme in the Alertmanager URL, http is assumed", "url", u.Redacted())
+	return nil
+}
+
+// amAddress returns the host:port, API path and scheme to reach the
+// Alertmanager. The scheme defaults to http, and the port to 443 for https
+// or 9093 otherwise. A URL without scheme, like 'host' or 'host:port', is
+// taken as the address of the Alertmanager.
+func amAddress(amURL *url.URL) (string, string, string) {
+	u := *amURL
+	switch {
+	case u.Opaque != "":
+		// 'host:port' is parsed as the 'host' scheme with an opaque 'port'.
+		port, p, _ := strings.Cut(u.Opaque, "/")
+		u = url.URL{Host: u.Scheme + ":" + port, Path: "/" + p}
+	case u.Host == "" && u.Scheme == "" && u.Path != "" && !strings.HasPrefix(u.Path, "/"):
+		host, p, _ := strings.Cut(u.Path, "/")
+		u.Host, u.Path = host, "/"+p
+	}
+
+	scheme := "http"
+	if u.Scheme != "" {
+		scheme = u.Scheme
+	}
 
-	if amURL.Host != "" {
-		address = amURL.Host // URL documents host as host or host:port
+	host, port := u.Hostname(), u.Port()
+	if host == "" {
+		host = defaultAmHost
 	}
-	if amURL.Scheme != "" {
-		schemes = []string{amURL.Scheme}
+	if port == "" {
+		port = defaultAmPort
+		if scheme == "https" {
+			port = "443"
+		}
 	}
+	return net.JoinHostPort(host, port), path.Join(u.Path, defaultAmApiv2path), scheme
+}
 
-	cr := clientruntime.New(address, path.Join(amURL.Path, defaultAmApiv2path), schemes)
+// NewAlertmanagerClient initializes an alertmanager client with the given URL.
+func NewAlertmanagerClient(amURL *url.URL, httpConfig promconfig.HTTPClientConfig) (*client.AlertmanagerAPI, error) {
+	cr, err := newClientRuntime(amURL, httpConfig)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cr, strfmt.Default), nil
+}
 
-	if amURL.User != nil && httpConfigFile != "" {
-		kingpin.Fatalf("basic authentication and http.config.file are mutually exclusive")
+// checkBasicAuth returns an error when the Alertmanager URL has credentials
+// as well as other credentials are given by the flags.
+func checkBasicAuth(amURL *url.URL) error {
+	switch {
+	case amURL.User == nil:
+		return nil
+	case httpConfigFile != "":
+		return errors.New("basic authentication and http.config.file are mutually exclusive")
+	case bearerToken != "" || bearerTokenFile != "":
+		return errors.New("basic authentication and bearer token are mutually exclusive")
+	case oauth2ClientID != "":
+		return errors.New("basic authentication and oauth2 are mutually exclusive")
 	}
+	return nil
+}
+
+// newClientRuntime returns the runtime sending the requests of a client to
+// the Alertmanager.
+func newClientRuntime(amURL *url.URL, httpConfig promconfig.HTTPClientConfig) (*clientruntime.Runtime, error) {
+	address, basePath, scheme := amAddress(amURL)
+	schemes := []string{scheme}
 
+	if err := checkBasicAuth(amURL); err != nil {
+		return nil, err
+	}
+	httpclient, err := newHTTPClient(httpConfig)
+	if err != nil {
+		return nil, err
+	}
+	cr := clientruntime.NewWithClient(address, basePath, schemes, httpclient)
 	if amURL.User != nil {
 		password, _ := amURL.User.Password()
 		cr.DefaultAuthentication = clientruntime.BasicAuth(amURL.User.Username(), password)
 	}
+	return cr, nil
+}
 
+// newHTTPClient returns the HTTP client of the HTTP config, tracing,
+// printing and rate limiting its requests as set by the flags.
+func newHTTPClient(httpConfig promconfig.HTTPClientConfig) (*http.Client, error) {
 	httpclient, err := promconfig.NewClientFromConfig(httpConfig, "atm")
 	if err != nil {
-		kingpin.Fatalf("failed to create a new HTTP client: %v", err)
+		return nil, fmt.Errorf("failed to create a new HTTP client: %v", err)
 	}
-	cr = clientruntime.NewWithClient(address, path.Join(amURL.Path, defaultAmApiv2path), schemes, httpclient)
-
-	return client.New(cr, strfmt.Default)
+	if httpTrace {
+		httpclient.Transport = &tracingRoundTripper{rt: httpclient.Transport}
+	}
+	if verbose {
+		httpclient.Transport = &verboseRoundTripper{config: httpConfig, rt: httpclient.Transport}
+	}
+	if l := getRequestLimiter(); l != nil {
+		httpclient.Transport = &limitedRoundTripper{limiter: l, rt: httpclient.Transport}
+	}
+	return httpclient, nil
 }
 
 // Execute is the main function for the atm command.
 func Execute() {
-	app := kingpin.New("atm", helpRoot).UsageWriter(os.Stdout)
+	app := newApp()
+
+	files, err := selectedConfigFiles(app, os.Args[1:])
+	if err != nil {
+		fatal(app, fmt.Errorf("could not load config file: %v", err))
+	}
+	resolver, err := newConfigResolver(files, legacyFlags)
+	if err != nil {
+		fatal(app, fmt.Errorf("could not load config file: %v", err))
+	}
+
+	err = resolver.bind(app, os.Args[1:])
+	// A partial flag being completed isn't an error.
+	if err != nil && !isCompletion(os.Args[1:]) {
+		fatal(app, err)
+	}
+
+	_, err = app.Parse(os.Args[1:])
+	if werr := writeOutputFile(); werr != nil {
+		fatal(app, fmt.Errorf("Unable to write output file: %v", werr))
+	}
+	if err != nil {
+		fatal(app, err)
+	}
+}
+
+// newApp returns the atm application, with its flags and commands.
+func newApp() *kingpin.Application {
+	app := kingpin.New("atm", helpRoot).UsageWriter(os.Stdout).DefaultEnvars()
 
 	format.InitFormatFlags(app)
 
-	app.Flag("alertmanager.url", "Alertmanager to talk to").URLVar(&alertmanagerURL)
-	app.Flag("timeout", "Timeout for the executed command").Default("30s").DurationVar(&timeout)
+	app.Flag("config.file", "Config file to read instead of the default ones").PlaceHolder("<filename>").StringVar(&configFile)
+	app.Flag("no-config", "Do not read any config file, only the flags and environment variables. Mutually exclusive with --config.file").BoolVar(&noConfig)
+	app.Flag("alertmanager.url", "Alertmanager to talk to. Repeat it or give comma separated URLs to fail over to the next ones when an Alertmanager is down").Envar(amURLEnvar()).SetValue(&alertmanagerURLs)
+	app.Flag("output", "Output formatter (simple, extended, json, csv, template, yaml)").Short('o').Default("simple").EnumVar(&output, "simple", "extended", "json", "csv", "template", "yaml")
+	app.Flag("color", "Colorize the silence states of the table outputs (auto, always, never), auto being disabled by NO_COLOR").Default("auto").EnumVar(&colorMode, "auto", "always", "never")
+	app.Flag("template", "Go template rendering each result with --output=template, like '{{.SilenceID}} {{.Tenant}}'").StringVar(&outputTemplate)
+	app.Flag("log.level", "Only log messages with the given severity or above (debug, info, warn, error)").Default("info").EnumVar(&logLevel, "debug", "info", "warn", "error")
+	app.Flag("quiet", "Only print the results and the errors, without progress messages and summaries").Short('q').BoolVar(&quiet)
+	app.Flag("progress", "Draw a progress bar on stderr during the multi-tenant runs on terminals, disabled with --no-progress").Default("true").BoolVar(&showProgress)
+	app.Flag("verbose", "Print the requests sent to Alertmanager to stderr, with the secrets redacted").Short('v').BoolVar(&verbose)
+	app.Flag("verbose.show-secrets", "Do not redact the authorization and tenant headers with --verbose").BoolVar(&verboseShowSecrets)
+	app.Flag("http.trace", "Log the method, URL, status and latency of every HTTP request, retries and redirects included").BoolVar(&httpTrace)
+	app.Flag("error-format", "Format of the error printed to stderr when atm fails (text, json)").Default("text").EnumVar(&errorFormat, "text", "json")
+	app.Flag("log.format", "Output format of log messages (logfmt, json)").Default("logfmt").EnumVar(&logFormat, "logfmt", "json")
+	app.Flag("output-file", "Write the results to this file instead of stdout").PlaceHolder("<filename>").StringVar(&outputFile)
+	app.Flag("append", "Append the results to the output file instead of replacing it").BoolVar(&appendOutput)
+	app.Flag("timeout", "Timeout of each request to Alertmanager, 0 means no timeout").Default("30s").DurationVar(&timeout)
+	app.Flag("skipped-tenants-file", "File to write the tenants skipped when --deadline is reached to, as a tenant file").PlaceHolder("<filename>").StringVar(&skippedTenantsFile)
+	app.Flag("deadline", "Timeout for the whole executed command, 0 means no deadline").Default("0s").DurationVar(&deadline)
+	app.Flag("retries", "Number of retries of the requests failing with a server or network error").Default("0").IntVar(&retries)
+	app.Flag("retry-backoff", "Initial wait between retries, doubled after each retry").Default("1s").DurationVar(&retryBackoff)
+	app.Flag("rps", "Maximum number of requests per second sent to Alertmanager, 0 means unlimited").Float64Var(&rps)
+	app.Flag("max-rate", "Deprecated alias of --rps").Float64Var(&rps)
 	app.Flag("http.config.file", "HTTP client configuration file for atm to connect to Alertmanager.").PlaceHolder("<filename>").ExistingFileVar(&httpConfigFile)
+	app.Flag("http.config.dir", "Directory of the HTTP client configuration files of the tenants, named <tenant>.yml, instead of --http.config.file").PlaceHolder("<dir>").ExistingDirVar(&httpConfigDir)
+	app.Flag("tls.cert-file", "Client certificate file to connect to Alertmanager").PlaceHolder("<filename>").ExistingFileVar(&tlsCertFile)
+	app.Flag("tls.key-file", "Client key file to connect to Alertmanager").PlaceHolder("<filename>").ExistingFileVar(&tlsKeyFile)
+	app.Flag("tls.ca-file", "CA certificate file to validate the Alertmanager certificate").PlaceHolder("<filename>").ExistingFileVar(&tlsCAFile)
+	app.Flag("tls.server-name", "Server name to validate the Alertmanager certificate").StringVar(&tlsServerName)
+	app.Flag("bearer-token", "Bearer token to authenticate to Alertmanager").StringVar(&bearerToken)
+	app.Flag("bearer-token-file", "File to read the bearer token from, on each request").PlaceHolder("<filename>").ExistingFileVar(&bearerTokenFile)
+	app.Flag("oauth2.client-id", "OAuth2 client ID to authenticate to Alertmanager with client credentials").StringVar(&oauth2ClientID)
+	app.Flag("oauth2.client-secret", "OAuth2 client secret").StringVar(&oauth2Secret)
+	app.Flag("oauth2.token-url", "OAuth2 token endpoint").StringVar(&oauth2TokenURL)
+	app.Flag("oauth2.scopes", "Comma separated OAuth2 scopes").StringVar(&oauth2Scopes)
+	app.Flag("header", "Extra HTTP header sent on every request, as 'Name: Value'. Repeat for multiple headers").PlaceHolder("'Name: Value'").StringsVar(&headers)
+	app.Flag("tls.insecure-skip-verify", "Disable the validation of the Alertmanager certificate").BoolVar(&tlsInsecure)
+
+	app.PreAction(setupLogger)
+	app.PreAction(validateRetryFlags)
+	app.PreAction(setupOutputFile)
+	app.PreAction(setupTemplate)
+	app.PreAction(setupColor)
 
 	app.Version(version.Print("atm"))
-	app.GetFlag("help").Short('h')
+	app.GetFlag("help").Short('h').NoEnvar()
+	app.GetFlag("version").NoEnvar()
 	app.UsageTemplate(kingpin.CompactUsageTemplate)
 
-	resolver, err := config.NewResolver(configFiles, legacyFlags)
-	if err != nil {
-		kingpin.Fatalf("could not load config file: %v\n", err)
-	}
-
 	configureSilenceCmd(app)
+	configureAlertCmd(app)
+	configureStatusCmd(app)
+	configureConfigCmd(app)
+	configureCompletionCmd(app)
+	configureVersionCmd(app)
+	configureDoctorCmd(app)
+	return app
+}
 
-	err = resolver.Bind(app, os.Args[1:])
-	if err != nil {
-		kingpin.Fatalf("%v\n", err)
-	}
+// configureCompletionCmd represents the completion command.
+func configureCompletionCmd(app *kingpin.Application) {
+	var shell string
+	completionCmd := app.Command("completion", "Print the completion script of the shell, e.g. source <(atm completion bash)")
+	completionCmd.Arg("shell", "Shell to complete (bash, zsh, fish)").Required().EnumVar(&shell, "bash", "zsh", "fish")
+	completionCmd.Action(func(pc *kingpin.ParseContext) error {
+		tmpl := map[string]string{
+			"bash": kingpin.BashCompletionTemplate,
+			"zsh":  kingpin.ZshCompletionTemplate,
+			"fish": fishCompletionTemplate,
+		}[shell]
+		app.Writer(os.Stdout)
+		return app.UsageForContextWithTemplate(pc, 2, tmpl)
+	})
+}
 
-	_, err = app.Parse(os.Args[1:])
-	if err != nil {
-		kingpin.Fatalf("%v\n", err)
+// isCompletion reports whether atm is called by a completion script.
+func isCompletion(args []string) bool {
+	for _, arg := range args {
+		if arg == "--completion-bash" {
+			return true
+		}
 	}
+	return false
 }
 
+// fishCompletionTemplate asks atm for the completions of the current command
+// line, like the bash and zsh scripts of kingpin do.
+const fishCompletionTemplate = `
+function __complete_{{.App.Name}}
+    set -l tokens (commandline -opc) (commandline -ct)
+    {{.App.Name}} --completion-bash $tokens[2..-1]
+end
+complete -c {{.App.Name}} -f -a '(__complete_{{.App.Name}})'
+`
+
 const (
 	helpRoot = `Alertmanager silences distributor.
 
 Config File:
 The atm tool will read a config file in YAML format from one of two
-default config locations: $HOME/.config/atm/config.yml or
-/etc/atm/config.yml
+default config locations: $XDG_CONFIG_HOME/atm/config.yml, $XDG_CONFIG_HOME
+defaulting to $HOME/.config, or /etc/atm/config.yml, or only from the file
+given by --config.file. --no-config disables the config files
 
 All flags can be given in the config file, but the following are the suited for
 static configuration:
@@ -163,8 +512,36 @@ static configuration:
 	require-comment
 		Bool, whether to require a comment on silence creation. Defaults to true
 
+	output
+		Set a default output type. Options are (simple, extended, json, csv, template, yaml)
+
+	date.format
+		Sets the output format for dates. Defaults to "2006-01-02 15:04:05 MST"
+
 	http.config.file
 		HTTP client configuration file for atm to connect to Alertmanager.
 		The format is https://prometheus.io/docs/alerting/latest/configuration/#http_config.
+
+	http.config.dir
+		Directory of the HTTP client configuration files of the tenants, named
+		<tenant>.yml. The tenants without one use http.config.file.
+
+	tenants
+		List of tenants used when neither --tenant nor --tenant.file is given
+
+	tenant.http-header, or tenant_http_header
+		Set the tenant HTTP header. Defaults to "X-Scope-OrgID"
+
+Environment Variables:
+Each flag can also be set by an ATM_ prefixed environment variable, like
+ATM_TIMEOUT for --timeout or ATM_TENANT_HTTP_HEADER for --tenant.http-header.
+The URL is also read from ALERTMANAGER_URL. The flags take precedence over the
+environment variables, which take precedence over the config file.
+
+Exit Codes:
+	0	success
+	1	error, nothing was done
+	2	partial failure, the command failed for some tenants or silences only
+	3	the deadline was reached before processing all the tenants
 `
 )
@@ -19,6 +19,15 @@ import (
 
 // configureSilenceCmd represents the silence command.
 func configureSilenceCmd(app *kingpin.Application) {
-	silenceCmd := app.Command("silence", "Add silences. For more information and additional flags see help").PreAction(requireAlertManagerURL)
+	silenceCmd := app.Command("silence", "Add, get, query, count, update, extend, watch, expire, export or import silences. For more information and additional flags see help").PreAction(requireAlertManagerURL)
 	configureSilenceAddCmd(silenceCmd)
+	configureSilenceGetCmd(silenceCmd)
+	configureSilenceQueryCmd(silenceCmd)
+	configureSilenceCountCmd(silenceCmd)
+	configureSilenceUpdateCmd(silenceCmd)
+	configureSilenceExtendCmd(silenceCmd)
+	configureSilenceWatchCmd(silenceCmd)
+	configureSilenceExpireCmd(silenceCmd)
+	configureSilenceExportCmd(silenceCmd)
+	configureSilenceImportCmd(silenceCmd)
 }
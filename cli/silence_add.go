@@ -16,44 +16,78 @@ package cli
 import (
 	"bufio"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"os/user"
-	"strconv"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/alecthomas/kingpin/v2"
 	"github.com/go-openapi/strfmt"
-	promconfig "github.com/prometheus/common/config"
 	"github.com/prometheus/common/model"
 
+	"github.com/prometheus/alertmanager/api/v2/client"
 	"github.com/prometheus/alertmanager/api/v2/client/silence"
 	"github.com/prometheus/alertmanager/api/v2/models"
 	"github.com/prometheus/alertmanager/matchers/compat"
 	"github.com/prometheus/alertmanager/pkg/labels"
 )
 
+// username returns the current user, falling back to $USER and $LOGNAME when
+// it can't be looked up, like in minimal containers. It's empty otherwise.
 func username() string {
-	user, err := user.Current()
-	if err != nil {
-		return ""
+	if user, err := user.Current(); err == nil && user.Username != "" {
+		return user.Username
+	}
+	for _, env := range []string{"USER", "LOGNAME"} {
+		if name := os.Getenv(env); name != "" {
+			return name
+		}
 	}
-	return user.Username
+	return ""
 }
 
 type silenceAddCmd struct {
-	author           string
-	requireComment   bool
-	duration         string
-	maxDuration      string
-	start            string
-	end              string
-	comment          string
-	matchers         []string
-	tenant           string
-	tenantFile       string
-	tenantHTTPHeader string
+	author          string
+	createdBySuffix string
+	requireComment  bool
+	duration        string
+	maxDuration     string
+	start           string
+	end             string
+	comment         string
+	matchers        []string
+	matchersFile    string
+	concurrency     int
+	dryRun          bool
+	timezone        string
+	commentFile     string
+	checkMatch      bool
+	strictMatch     bool
+	fromAlert       string
+	alertLabels     []string
+	allAlerts       bool
+	broadcast       bool
+	exclude         []string
+	labelFile       string
+	alertname       string
+	regexAnchored   bool
+	idempotencyKey  string
+	meta            map[string]string
+	force           bool
+	minSpecificity  int
+	skipDuplicate   bool
+	wait            bool
+	tenantFlags
 }
 
 const silenceAddHelp = `Add a new alertmanager silence
@@ -78,66 +112,410 @@ const silenceAddHelp = `Add a new alertmanager silence
 	As well as direct equality, regex matching is also supported. The '=~' syntax
 	(similar to Prometheus) is used to represent a regex match. Regex matching
 	can be used in combination with a direct match.
+
+  atm silence add --matchers-file silences.txt
+
+	Each line of the file is a matcher group, using the same space separated
+	syntax as above, which creates its own silence. Use '-' to read the
+	matcher groups from stdin. Blank lines and lines starting with '#' are
+	ignored.
+
+  atm silence add foo --tenant.file tenants.conf --comment 'maintenance of {{ .Tenant }}'
+
+	The comment is a Go template rendered for each tenant, with the .Tenant,
+	.Author and .Matchers values.
+
+  atm silence add foo --start now+1h --end +2h
+
+	The start and end of the silence can be relative: 'now', 'now+2h' and
+	'now-30m' are relative to the current time. A duration like '+2h' or
+	'-30m' is relative to the current time for --start, but relative to the
+	start of the silence for --end. This example adds a 2h silence starting
+	in an hour. Negative values must be given as --start=-30m.
+
+  atm silence add --from-alert 'alertname=foo' --label alertname --label instance
+
+	The matchers are built from the labels of the current alert matching
+	--from-alert, given either as a fingerprint or as a matcher. With --label
+	only the selected labels are used, otherwise all of them. If several
+	alerts match, --all adds a silence for each of them.
+
+  atm silence add 'alertname=~Disk.*' --exclude env=prod --exclude team=db
+
+	Each --exclude appends a negative matcher, env!=prod and team!=db here,
+	to the matchers of every silence. At least one positive matcher is
+	required so that the silence isn't too broad.
+
+  atm silence add --alertname 'Disk*' instance=db1
+
+	--alertname adds an alertname matcher to every silence. A value with
+	'*' or '?' wildcards is a glob, turned into the 'alertname=~Disk.*'
+	regex matcher here, a value prefixed with '~' is a regex, and any other
+	value an 'alertname=value' matcher.
+
+  atm silence add 'instance=~db' --no-regex-anchored
+
+	Like Prometheus, Alertmanager anchors the regex matchers, so that
+	'instance=~db' only matches the 'db' instance. With --no-regex-anchored,
+	the regexes match substrings like grep, 'instance=~db' being sent as
+	'instance=~.*(?:db).*', except where anchored with '^' or '$'.
+
+  atm silence add alertname=Deploy --label-file labels.properties
+
+	Each 'name=value' line of the file appends an equality matcher, and
+	each 'name=~regex' line a regex matcher, to the matchers of every
+	silence. Blank lines and lines starting with '#' are ignored.
+
+  atm silence add 'alertname=~.*' cluster=eu-1 --min-specificity 2 --force
+
+	A silence needs --min-specificity positive matchers not matching every
+	alert, 1 by default, otherwise it's rejected unless --force is given.
+	A silence with negative matchers only, or with 'alertname=~.*' as its
+	only positive matcher, would silence the whole cluster.
+
+  atm silence add --matchers-file silences.txt --skip-duplicate
+
+	The active and pending silences of each tenant are queried first, and a
+	silence is not added when one with the same matchers already covers its
+	period, whatever its comment. The ID of the existing silence is printed
+	instead.
+
+  atm silence add alertname=foo --start 2024-07-02T20:00:00Z --end +2h --idempotency-key auto
+
+	The silence comment gets an 'atm-key:<hash>' marker of the key and the
+	matchers. Before adding the silence, the active and pending silences of
+	the tenant are queried, and when one has the marker its ID is printed
+	instead, so that a CI job can be run again safely. With 'auto', the key
+	is the period of the silence as given by --start, and --end or
+	--duration, so that a relative period like the default one is the same
+	from one run to the next.
+
+  atm silence add alertname=foo --comment 'db upgrade' --meta incident=INC-42 --meta team=db
+
+	The metadata are added to the comment as a last 'atm-meta: <json>'
+	line, the human part of the comment being unchanged. They are shown by
+	silence query --show-meta.
+
+  atm silence add alertname=foo --start now+10s --wait --deadline 1m
+
+	With --wait, atm returns once the silence is active, polling it every
+	second, which is useful before deploying. Use --deadline to bound the
+	wait.
+
+  atm silence add alertname=foo --check-match
+
+	Before adding the silence, the current alerts are queried and a warning
+	is printed if none of them matches. With --strict-match the silence is
+	not added in this case.
 `
 
 func configureSilenceAddCmd(cc *kingpin.CmdClause) {
 	var (
-		c      = &silenceAddCmd{}
+		c      = &silenceAddCmd{meta: map[string]string{}}
 		addCmd = cc.Command("add", silenceAddHelp)
 	)
-	addCmd.Flag("tenant", "tenant").Short('t').StringVar(&c.tenant)
-	addCmd.Flag("tenant.http-header", "tenant HTTP Header").Default("X-Scope-OrgID").StringVar(&c.tenantHTTPHeader)
-	addCmd.Flag("tenant.file", "tenant file location").PlaceHolder("<filename>").ExistingFileVar(&c.tenantFile)
+	c.tenantFlags.configure(addCmd)
 	addCmd.Flag("author", "Username for CreatedBy field").Short('a').Default(username()).StringVar(&c.author)
+	addCmd.Flag("created-by-suffix", "Suffix appended to the author in the CreatedBy field, to tell the silences added by atm. Empty to disable").Default(" (via atm)").StringVar(&c.createdBySuffix)
 	addCmd.Flag("require-comment", "Require comment to be set").Hidden().Default("true").BoolVar(&c.requireComment)
 	addCmd.Flag("duration", "Duration of silence").Short('d').Default("1h").StringVar(&c.duration)
 	addCmd.Flag("max-duration", "Max Duration of silence").Default("12h").StringVar(&c.maxDuration)
-	addCmd.Flag("start", "Set when the silence should start. RFC3339 format 2006-01-02T15:04:05-07:00").StringVar(&c.start)
-	addCmd.Flag("end", "Set when the silence should end (overwrites duration). RFC3339 format 2006-01-02T15:04:05-07:00").StringVar(&c.end)
+	addCmd.Flag("start", "Set when the silence should start. RFC3339 format 2006-01-02T15:04:05-07:00, or relative to now like now+2h or -30m").StringVar(&c.start)
+	addCmd.Flag("end", "Set when the silence should end (overwrites duration). RFC3339 format 2006-01-02T15:04:05-07:00, or relative like +2h to the start or now+2h").StringVar(&c.end)
+	addCmd.Flag("timezone", "Timezone of the start and end times given without offset, like 2006-01-02T15:04:05").Default("Local").StringVar(&c.timezone)
 	addCmd.Flag("comment", "A comment to help describe the silence").Short('c').StringVar(&c.comment)
+	stdinFileVar(addCmd.Flag("comment-file", "File to read the comment from. Use '-' for stdin").PlaceHolder("<filename>"), &c.commentFile)
+	addCmd.Flag("concurrency", "Number of tenants to add the silence to in parallel").Default("8").IntVar(&c.concurrency)
+	addCmd.Flag("check-match", "Warn when the silence matches no current alert").BoolVar(&c.checkMatch)
+	addCmd.Flag("strict-match", "Fail when the silence matches no current alert").BoolVar(&c.strictMatch)
+	addCmd.Flag("from-alert", "Build the matchers from the labels of the alert with this fingerprint, or matching this matcher").PlaceHolder("<fingerprint|matcher>").StringVar(&c.fromAlert)
+	addCmd.Flag("label", "Label of the alert given by --from-alert to silence on, all of them by default").StringsVar(&c.alertLabels)
+	addCmd.Flag("all", "Add a silence for each alert matching --from-alert").BoolVar(&c.allAlerts)
+	addCmd.Flag("broadcast", "Add the silence to every peer of the Alertmanager cluster instead of letting it gossip, the peers API being on the port of --alertmanager.url").BoolVar(&c.broadcast)
+	addCmd.Flag("exclude", "Exclude the alerts with this label value, appending a 'name!=value' matcher. Repeat for multiple labels").PlaceHolder("name=value").StringsVar(&c.exclude)
+	addCmd.Flag("alertname", "Alertname to silence, a glob with '*' and '?' wildcards, or a regex prefixed with '~'").PlaceHolder("<name|glob|~regex>").StringVar(&c.alertname)
+	addCmd.Flag("regex-anchored", "Regex matchers match the whole label value, as done by Alertmanager. With --no-regex-anchored, they match substrings").Default("true").BoolVar(&c.regexAnchored)
+	addCmd.Flag("label-file", "Properties file with a 'name=value' or 'name=~regex' matcher per line, appended to the matchers of every silence").PlaceHolder("<filename>").StringVar(&c.labelFile)
+	addCmd.Flag("min-specificity", "Minimum number of positive matchers not matching every alert, 0 to disable the check").Default("1").IntVar(&c.minSpecificity)
+	addCmd.Flag("force", "Add the silence even when it has less than --min-specificity specific matchers, or lasts longer than --max-duration").BoolVar(&c.force)
+	addCmd.Flag("skip-duplicate", "Do not add the silence when an active one with the same matchers already covers its period").BoolVar(&c.skipDuplicate)
+	addCmd.Flag("idempotency-key", "Key stored in the comment of the silence, not added again when an active silence has it. 'auto' derives it from the matchers and the period").PlaceHolder("<key|auto>").StringVar(&c.idempotencyKey)
+	addCmd.Flag("meta", "Metadata 'key=value' stored as JSON on the last line of the comment, like an incident ID. Can be repeated").PlaceHolder("key=value").StringMapVar(&c.meta)
+	addCmd.Flag("wait", "Wait for the silence to be active before returning, polling it every second. Bounded by --deadline").BoolVar(&c.wait)
+	addCmd.Flag("dry-run", "Validate the silences and print their payload without adding them").BoolVar(&c.dryRun)
+	stdinFileVar(addCmd.Flag("matchers-file", "File with a matcher group per line, each one creating a silence. Use '-' for stdin").PlaceHolder("<filename>"), &c.matchersFile)
 	addCmd.Arg("matcher-groups", "Query filter").StringsVar(&c.matchers)
 	addCmd.Action(execWithTimeout(c.add))
 }
 
-func (c *silenceAddCmd) add(ctx context.Context, _ *kingpin.ParseContext) error {
-	var err error
+// matcherGroup holds the matchers of a silence, with the line they were read
+// from when using a matchers file.
+type matcherGroup struct {
+	line int
+	args []string
+}
 
+func (c *silenceAddCmd) matcherGroups() ([]matcherGroup, error) {
+	if c.matchersFile == "" {
+		return []matcherGroup{{args: c.matchers}}, nil
+	}
 	if len(c.matchers) > 0 {
-		// If the parser fails then we likely don't have a (=|=~|!=|!~) so lets
-		// assume that the user wants alertname=<arg> and prepend `alertname=`
-		// to the front.
-		_, err := compat.Matcher(c.matchers[0], "cli")
+		return nil, errors.New("matcher-groups and matchers-file are mutually exclusive")
+	}
+
+	r := os.Stdin
+	if c.matchersFile != "-" {
+		f, err := os.Open(c.matchersFile)
 		if err != nil {
-			c.matchers[0] = fmt.Sprintf("alertname=%s", strconv.Quote(c.matchers[0]))
+			return nil, fmt.Errorf("Unable to read matchers file '%s': %v", c.matchersFile, err)
 		}
+		defer f.Close()
+		r = f
 	}
 
-	matchers := make([]labels.Matcher, 0, len(c.matchers))
-	for _, s := range c.matchers {
-		m, err := compat.Matcher(s, "cli")
-		if err != nil {
-			return err
+	var groups []matcherGroup
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
 		}
+		groups = append(groups, matcherGroup{line: line, args: strings.Fields(text)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to read matchers file '%s': %v", c.matchersFile, err)
+	}
+	if len(groups) < 1 {
+		return nil, fmt.Errorf("no matchers specified")
+	}
+	return groups, nil
+}
+
+// alertMatcherGroups returns a matcher group built from the labels of each
+// alert selected by --from-alert.
+func (c *silenceAddCmd) alertMatcherGroups(ctx context.Context) ([]matcherGroup, error) {
+	if len(c.matchers) > 0 || c.matchersFile != "" {
+		return nil, errors.New("from-alert is mutually exclusive with matcher-groups and matchers-file")
+	}
+	if c.tenantFile != "" {
+		return nil, errors.New("from-alert is mutually exclusive with tenant.file")
+	}
+	if c.multiTenant() {
+		return nil, errors.New("from-alert requires --tenant when the config file has tenants")
+	}
+
+	// A fingerprint isn't a valid matcher, so it is looked up among all alerts.
+	var fingerprint string
+	m, err := compat.Matcher(c.fromAlert, "cli")
+	if err != nil {
+		fingerprint = c.fromAlert
+	}
+	var matchers []labels.Matcher
+	if m != nil {
 		matchers = append(matchers, *m)
 	}
-	if len(matchers) < 1 {
-		return fmt.Errorf("no matchers specified")
+
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	amclient, err := c.newClient(httpConfig, c.tenant)
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := getAlerts(ctx, amclient, c.tenant, matchers)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to query alerts%s: %v", forTenant(c.tenant), err)
+	}
+	if fingerprint != "" {
+		var found models.GettableAlerts
+		for _, a := range alerts {
+			if *a.Fingerprint == fingerprint {
+				found = append(found, a)
+			}
+		}
+		alerts = found
 	}
 
-	var startsAt time.Time
+	switch {
+	case len(alerts) < 1:
+		return nil, fmt.Errorf("no alert found for '%s'%s", c.fromAlert, forTenant(c.tenant))
+	case len(alerts) > 1 && !c.allAlerts:
+		return nil, fmt.Errorf("%d alerts found for '%s'%s, use --all to silence all of them", len(alerts), c.fromAlert, forTenant(c.tenant))
+	}
+
+	var (
+		groups []matcherGroup
+		seen   = map[string]bool{}
+	)
+	for _, a := range alerts {
+		names := c.alertLabels
+		if len(names) < 1 {
+			names = make([]string, 0, len(a.Labels))
+			for name := range a.Labels {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+		}
+
+		args := make([]string, 0, len(names))
+		for _, name := range names {
+			value, ok := a.Labels[name]
+			if !ok {
+				return nil, fmt.Errorf("label '%s' not found on alert '%s'", name, *a.Fingerprint)
+			}
+			lm := labels.Matcher{Type: labels.MatchEqual, Name: name, Value: value}
+			args = append(args, lm.String())
+		}
+		// Alerts only differing by the unselected labels share a silence.
+		key := strings.Join(args, " ")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		groups = append(groups, matcherGroup{args: args})
+	}
+	return groups, nil
+}
+
+// forLine returns the message suffix naming the matchers file line, if any.
+func forLine(line int) string {
+	if line == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (line %d)", line)
+}
+
+// lineLogger returns the logger, logging the matchers file line if any.
+func lineLogger(line int) *slog.Logger {
+	if line == 0 {
+		return logger
+	}
+	return logger.With("line", line)
+}
+
+// commentData holds the values available in the comment template.
+type commentData struct {
+	Tenant   string
+	Author   string
+	Matchers string
+}
+
+// parseCommentTemplate parses the comment template, of the tenant config of
+// the tenant if any, failing before adding any silence if it uses an unknown
+// field.
+func parseCommentTemplate(comment, tenant string) (*template.Template, error) {
+	tmpl, err := template.New("comment").Parse(comment)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment template%s: %v", forTenant(tenant), err)
+	}
+	if err := tmpl.Execute(io.Discard, commentData{}); err != nil {
+		return nil, fmt.Errorf("invalid comment template%s: %v", forTenant(tenant), err)
+	}
+	return tmpl, nil
+}
+
+// commentMetaPrefix starts the last line of a comment holding the metadata
+// of the silence as JSON.
+const commentMetaPrefix = "atm-meta: "
+
+// withCommentMeta returns the comment with the metadata, if any, as JSON on
+// a line of its own after it.
+func withCommentMeta(comment string, meta map[string]string) string {
+	if len(meta) == 0 {
+		return comment
+	}
+	// A map of strings is always marshaled, with its keys sorted.
+	b, _ := json.Marshal(meta)
+	line := commentMetaPrefix + string(b)
+	if comment = strings.TrimRight(comment, "\n"); comment == "" {
+		return line
+	}
+	return comment + "\n" + line
+}
+
+// parseCommentMeta returns the human part of the comment, and the metadata
+// of its last line written by withCommentMeta, if any.
+func parseCommentMeta(comment string) (string, map[string]string) {
+	human, line := "", comment
+	if i := strings.LastIndex(comment, "\n"); i >= 0 {
+		human, line = comment[:i], comment[i+1:]
+	}
+	if !strings.HasPrefix(line, commentMetaPrefix) {
+		return comment, nil
+	}
+	var meta map[string]string
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, commentMetaPrefix)), &meta); err != nil {
+		return comment, nil
+	}
+	return human, meta
+}
+
+// readComment returns the comment read from the comment file, with a single
+// trailing newline trimmed.
+func (c *silenceAddCmd) readComment() (string, error) {
+	var (
+		b   []byte
+		err error
+	)
+	if c.commentFile == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(c.commentFile)
+	}
+	if err != nil {
+		return "", fmt.Errorf("Unable to read comment file '%s': %v", c.commentFile, err)
+	}
+	comment := strings.TrimSuffix(string(b), "\n")
+	return strings.TrimSuffix(comment, "\r"), nil
+}
+
+func (c *silenceAddCmd) add(ctx context.Context, _ *kingpin.ParseContext) error {
+	stdin := 0
+	for _, f := range []string{c.matchersFile, c.tenantFile, c.commentFile} {
+		if f == "-" {
+			stdin++
+		}
+	}
+	if stdin > 1 {
+		return errors.New("only one of matchers-file, tenant.file and comment-file can be read from stdin")
+	}
+
+	var (
+		groups []matcherGroup
+		err    error
+	)
+	if c.fromAlert != "" {
+		groups, err = c.alertMatcherGroups(ctx)
+	} else {
+		groups, err = c.matcherGroups()
+	}
+	if err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(c.timezone)
+	if err != nil {
+		return fmt.Errorf("invalid timezone '%s': %v", c.timezone, err)
+	}
+	md, err := parseMaxDuration(c.maxDuration)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	startsAt := now
 	if c.start != "" {
-		startsAt, err = time.Parse(time.RFC3339, c.start)
+		startsAt, err = parseTime(c.start, now, now, loc)
 		if err != nil {
 			return err
 		}
-
-	} else {
-		startsAt = time.Now().UTC()
 	}
 
 	var endsAt time.Time
 	if c.end != "" {
-		endsAt, err = time.Parse(time.RFC3339, c.end)
+		// A relative end is resolved against the start of the silence.
+		endsAt, err = parseTime(c.end, now, startsAt, loc)
 		if err != nil {
 			return err
 		}
@@ -150,108 +528,464 @@ func (c *silenceAddCmd) add(ctx context.Context, _ *kingpin.ParseContext) error
 			return fmt.Errorf("silence duration must be greater than 0")
 		}
 		endsAt = startsAt.UTC().Add(time.Duration(d))
-
-		md, _ := model.ParseDuration(c.maxDuration)
-		if d > md {
-			return fmt.Errorf("silence duration '%s' couldn't be greater than '%s'", c.duration, c.maxDuration)
-		}
 	}
 
-	if startsAt.After(endsAt) {
+	if !endsAt.After(startsAt) {
 		return errors.New("silence cannot start after it ends")
 	}
 
-	if c.requireComment && c.comment == "" {
-		return errors.New("comment required by config")
+	// The period is printed with each added silence otherwise.
+	period := silencePeriod(startsAt, endsAt, loc)
+	if (c.start != "" || c.end != "") && c.dryRun && !quiet {
+		fmt.Fprintf(os.Stderr, "Silence %s\n", period)
+	}
+
+	if c.commentFile != "" {
+		if c.comment != "" {
+			return errors.New("comment and comment-file are mutually exclusive")
+		}
+		c.comment, err = c.readComment()
+		if err != nil {
+			return err
+		}
 	}
 
-	start := strfmt.DateTime(startsAt)
-	end := strfmt.DateTime(endsAt)
-	ps := &models.PostableSilence{
-		Silence: models.Silence{
-			Matchers:  TypeMatchers(matchers),
-			StartsAt:  &start,
-			EndsAt:    &end,
-			CreatedBy: &c.author,
-			Comment:   &c.comment,
-		},
+	if strings.TrimSpace(c.author) == "" {
+		return errors.New("silence author is empty: set it with --author, as the current user couldn't be determined from the system, $USER or $LOGNAME")
 	}
-	silenceParams := silence.NewPostSilencesParams().WithContext(ctx).WithSilence(ps)
 
-	if c.tenant != "" && c.tenantFile != "" {
-		kingpin.Fatalf("tenant and tenant.file are mutually exclusive")
+	for k := range c.meta {
+		if strings.TrimSpace(k) == "" {
+			return errors.New("invalid --meta, the key is empty")
+		}
 	}
 
-	httpConfig := NewAlertmanagerClientConfig()
-	if c.tenant != "" {
-		httpConfig = setHTTPTenantHeader(httpConfig, c.tenant, c.tenantHTTPHeader)
-		amclient := NewAlertmanagerClient(alertmanagerURL, *httpConfig)
+	tmpl, err := parseCommentTemplate(c.comment, "")
+	if err != nil {
+		return err
+	}
 
-		postOk, err := amclient.Silence.PostSilences(silenceParams)
+	excludes, err := parseExcludes(c.exclude)
+	if err != nil {
+		return err
+	}
+	// The matchers of --label-file and --alertname are added to every silence.
+	var extraMatchers []labels.Matcher
+	if c.labelFile != "" {
+		if extraMatchers, err = readLabelFile(c.labelFile); err != nil {
+			return err
+		}
+		if extraMatchers, err = anchorRegexes(extraMatchers, c.regexAnchored); err != nil {
+			return err
+		}
+	}
+	if c.alertname != "" {
+		m, err := alertnameMatcher(c.alertname)
 		if err != nil {
-			return fmt.Errorf("Unable to add silence for '%s' tenant: %v", c.tenant, err)
+			return err
+		}
+		extraMatchers = append(extraMatchers, m)
+	}
+
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+	// The tenant configs can override the comment of their tenant.
+	tenantTmpls := map[string]*template.Template{}
+	for _, t := range tenants {
+		if comment, ok := c.tenantComment(t); ok {
+			if tenantTmpls[t], err = parseCommentTemplate(comment, t); err != nil {
+				return err
+			}
+		} else if c.requireComment && c.comment == "" {
+			return errors.New("comment required by config")
+		}
+	}
+
+	// The end given by --end is checked too, unless forced. The max duration
+	// is checked for each tenant when some have their own.
+	duration := model.Duration(endsAt.Sub(startsAt))
+	checkDuration := func(t string) error {
+		if c.force {
+			return nil
+		}
+		if limit, source := c.tenantMaxDuration(t, md); duration > limit {
+			return fmt.Errorf("silence duration '%s' couldn't be greater than '%s' set by %s, use --force to add it anyway", duration, limit, source)
 		}
-		fmt.Printf("Silence added for '%s' tenant: %s", c.tenant, postOk.Payload.SilenceID)
 		return nil
-	} else if c.tenantFile != "" {
+	}
+	if !c.tenantMaxDurations() {
+		if err := checkDuration(""); err != nil {
+			return err
+		}
+	}
 
-		tenants, err := readTenantFromFile(c.tenantFile)
+	var (
+		results    []silenceResult
+		addErr     error
+		skipped    []string
+		skippedSet = map[string]bool{}
+	)
+	added, failed := 0, 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		matchers, err := parseMatchers(g.args)
+		if err == nil {
+			matchers, err = anchorRegexes(matchers, c.regexAnchored)
+		}
+		matchers = append(matchers, extraMatchers...)
+		if err == nil && len(matchers) < 1 {
+			err = fmt.Errorf("no matchers specified")
+		}
+		if err == nil && len(excludes) > 0 {
+			matchers = append(matchers, excludes...)
+			if !hasPositiveMatcher(matchers) {
+				err = fmt.Errorf("at least one positive matcher is required with --exclude")
+			}
+		}
+		if err == nil {
+			var conflicts []string
+			matchers, conflicts = canonicalMatchers(matchers)
+			if len(conflicts) > 0 {
+				args := []interface{}{"matchers", strings.Join(conflicts, ", ")}
+				if g.line > 0 {
+					args = append(args, "line", g.line)
+				}
+				logger.Warn("Conflicting matchers, the silence can never match", args...)
+			}
+			err = validateMatchers(matchers)
+		}
+		if err == nil && !c.force {
+			err = checkSpecificity(matchers, c.minSpecificity)
+		}
 		if err != nil {
-			return err
+			if c.matchersFile == "" {
+				return err
+			}
+			failed++
+			results = append(results, silenceResult{Line: g.line, Status: "failed", Error: err.Error()})
+			logger.Error("Invalid matchers", "line", g.line, "err", err)
+			continue
 		}
 
-		for _, t := range tenants {
-			httpConfig = setHTTPTenantHeader(httpConfig, t, c.tenantHTTPHeader)
-			amclient := NewAlertmanagerClient(alertmanagerURL, *httpConfig)
+		var marker string
+		if c.idempotencyKey != "" {
+			marker = idempotencyMarker(c.idempotencyKey, c.idempotencyPeriod(startsAt, endsAt), matchers)
+		}
 
-			postOk, err := amclient.Silence.PostSilences(silenceParams)
+		// newSilence returns the silence to add for the tenant, with the
+		// comment template rendered for it.
+		newSilence := func(t string) (*models.PostableSilence, error) {
+			if err := checkDuration(t); err != nil {
+				return nil, err
+			}
+			tmpl := tmpl
+			if tt, ok := tenantTmpls[t]; ok {
+				tmpl = tt
+			}
+			var comment strings.Builder
+			data := commentData{Tenant: t, Author: c.author, Matchers: formatMatchers(TypeMatchers(matchers))}
+			if err := tmpl.Execute(&comment, data); err != nil {
+				return nil, fmt.Errorf("invalid comment template: %v", err)
+			}
+			rendered := comment.String()
+			if marker != "" {
+				rendered = strings.TrimSpace(rendered + " " + marker)
+			}
+			rendered = withCommentMeta(rendered, c.meta)
+			createdBy := c.author + c.createdBySuffix
+			start := strfmt.DateTime(startsAt)
+			end := strfmt.DateTime(endsAt)
+			return &models.PostableSilence{
+				Silence: models.Silence{
+					Matchers:  TypeMatchers(matchers),
+					StartsAt:  &start,
+					EndsAt:    &end,
+					CreatedBy: &createdBy,
+					Comment:   &rendered,
+				},
+			}, nil
+		}
+
+		if c.dryRun {
+			for _, t := range tenants {
+				sr := silenceResult{Tenant: t, Line: g.line, Status: "validated"}
+				ps, err := newSilence(t)
+				if err == nil {
+					err = ps.Validate(strfmt.Default)
+				}
+				if err != nil {
+					failed++
+					sr.Status, sr.Error = "failed", err.Error()
+					lineLogger(g.line).Error("Invalid silence", "tenant", t, "err", err)
+					recordFailure(t, err)
+					results = append(results, sr)
+					continue
+				}
+				payload, err := json.Marshal(ps)
+				if err != nil {
+					return err
+				}
+				added++
+				sr.Silence = ps
+				printf("Silence validated%s%s: %s\n", forTenant(t), forLine(g.line), payload)
+				results = append(results, sr)
+			}
+			continue
+		}
+
+		// duplicates holds the tenants already having the silence with
+		// --skip-duplicate or --idempotency-key.
+		var duplicates sync.Map
+		tenantResults, groupSkipped := forEachTenant(ctx, tenants, c.concurrency, func(t string) (string, error) {
+			ps, err := newSilence(t)
 			if err != nil {
-				fmt.Printf("Unable to add silence for '%s' tenant: %v\n", t, err)
-				continue
+				return "", err
+			}
+			amclient, err := c.newClient(httpConfig, t)
+			if err != nil {
+				return "", err
+			}
+			if c.skipDuplicate || marker != "" {
+				existing, err := activeSilences(ctx, amclient, t)
+				if err != nil {
+					return "", fmt.Errorf("unable to check duplicate silences: %v", err)
+				}
+				id := markedSilence(existing, marker)
+				if id == "" && c.skipDuplicate {
+					id = duplicateSilence(existing, matchers, startsAt, endsAt)
+				}
+				if id != "" {
+					duplicates.Store(t, true)
+					return id, nil
+				}
+			}
+			if c.checkMatch || c.strictMatch {
+				if err := c.checkMatchingAlerts(ctx, amclient, t, g.line, matchers); err != nil {
+					return "", err
+				}
+			}
+			var id string
+			if c.broadcast {
+				id, err = c.broadcastSilence(ctx, amclient, t, ps)
+			} else {
+				silenceParams := silence.NewPostSilencesParams().WithContext(ctx).WithTimeout(timeout).WithSilence(ps)
+				var postOk *silence.PostSilencesOK
+				err = withRetry(ctx, "add silence"+forTenant(t), func() (err error) {
+					postOk, err = amclient.Silence.PostSilences(silenceParams)
+					return err
+				})
+				if err == nil {
+					id = postOk.Payload.SilenceID
+				}
 			}
-			fmt.Printf("Silence added for '%s' tenant: %s\n", t, postOk.Payload.SilenceID)
+			if err != nil {
+				return "", err
+			}
+			if c.wait {
+				for _, id := range strings.Split(id, ",") {
+					if err := waitActive(ctx, amclient, t, id); err != nil {
+						return "", fmt.Errorf("silence '%s' added but not active: %v", id, err)
+					}
+				}
+			}
+			return id, nil
+		})
 
+		for _, t := range groupSkipped {
+			if !skippedSet[t] {
+				skippedSet[t] = true
+				skipped = append(skipped, t)
+			}
 		}
-	} else {
-		amclient := NewAlertmanagerClient(alertmanagerURL, *httpConfig)
 
-		postOk, err := amclient.Silence.PostSilences(silenceParams)
-		if err != nil {
-			return fmt.Errorf("Unable to add silence: %v", err)
+		for _, sr := range newSilenceResults(tenantResults, "added") {
+			sr.Line = g.line
+			if _, ok := duplicates.Load(sr.Tenant); ok {
+				sr.Status = "skipped"
+			}
+			if sr.Status == "added" {
+				start, end := startsAt.UTC(), endsAt.UTC()
+				sr.StartsAt, sr.EndsAt = &start, &end
+			}
+			results = append(results, sr)
 		}
-		fmt.Printf("Silence added: %s", postOk.Payload.SilenceID)
+
+		if !c.multiTenant() && c.matchersFile == "" && len(groups) == 1 && len(tenantResults) == 1 {
+			r := tenantResults[0]
+			if r.err != nil {
+				failed++
+				addErr = fmt.Errorf("Unable to add silence%s: %v", forTenant(r.tenant), r.err)
+				recordFailure(r.tenant, r.err)
+				continue
+			}
+			if _, ok := duplicates.Load(r.tenant); ok {
+				printResult(r.id, "Silence already exists%s: %s\n", forTenant(r.tenant), r.id)
+				continue
+			}
+			added++
+			printResult(r.id, "Silence added%s: %s, %s\n", forTenant(r.tenant), r.id, period)
+			continue
+		}
+
+		for _, r := range tenantResults {
+			if r.err != nil {
+				failed++
+				lineLogger(g.line).Error("Unable to add silence", "tenant", r.tenant, "err", r.err)
+				recordFailure(r.tenant, r.err)
+				continue
+			}
+			if _, ok := duplicates.Load(r.tenant); ok {
+				printResult(r.id, "Silence already exists%s%s: %s\n", forTenant(r.tenant), forLine(g.line), r.id)
+				continue
+			}
+			added++
+			printResult(r.id, "Silence added%s%s: %s, %s\n", forTenant(r.tenant), forLine(g.line), r.id, period)
+		}
+	}
+
+	if err := printResults(results); err != nil {
 		return err
 	}
+
+	if c.dryRun {
+		c.printSummary("validated", added, failed, len(tenants))
+		if failed > 0 {
+			return failure(added, fmt.Errorf("failed to validate %d silence(s)", failed))
+		}
+		return nil
+	}
+
+	c.printSummary("added", added, failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if addErr != nil {
+		return addErr
+	}
+	if failed > 0 {
+		return failure(added, fmt.Errorf("failed to add %d silence(s)", failed))
+	}
 	return nil
 }
 
-func readTenantFromFile(tenantFile string) ([]string, error) {
-	var tenants []string
+// idempotencyPeriod returns the period of the silence hashed by
+// --idempotency-key=auto: the absolute times in UTC, and the relative ones
+// and the duration as given, which are the same from one run to the next
+// unlike the times they are resolved to.
+func (c *silenceAddCmd) idempotencyPeriod(startsAt, endsAt time.Time) string {
+	start, end := c.start, c.end
+	switch {
+	case start == "":
+		start = "now"
+	case isAbsoluteTime(start):
+		start = startsAt.UTC().Format(time.RFC3339)
+	}
+	switch {
+	case end == "":
+		end = "+" + c.duration
+	case isAbsoluteTime(end):
+		end = endsAt.UTC().Format(time.RFC3339)
+	}
+	return start + "/" + end
+}
+
+// silencePeriod renders the start and end of the silence in the given
+// location and in UTC.
+func silencePeriod(startsAt, endsAt time.Time, loc *time.Location) string {
+	return fmt.Sprintf("from %s (%s) to %s (%s)",
+		startsAt.In(loc).Format(time.RFC3339), startsAt.UTC().Format(time.RFC3339),
+		endsAt.In(loc).Format(time.RFC3339), endsAt.UTC().Format(time.RFC3339),
+	)
+}
+
+// waitInterval is the time between two polls of a silence by --wait.
+var waitInterval = time.Second
 
-	readFile, err := os.Open(tenantFile)
+// waitActive polls the silence until it's active.
+func waitActive(ctx context.Context, amclient *client.AlertmanagerAPI, tenant, id string) error {
+	for {
+		gs, err := getSilence(ctx, amclient, tenant, id)
+		if err != nil {
+			return err
+		}
+		switch state := *gs.Status.State; state {
+		case models.SilenceStatusStateActive:
+			return nil
+		case models.SilenceStatusStateExpired:
+			return errors.New("silence expired")
+		default:
+			if verbose {
+				verboseMtx.Lock()
+				fmt.Fprintf(os.Stderr, "Waiting for silence '%s'%s to be active, state %s\n", id, forTenant(tenant), state)
+				verboseMtx.Unlock()
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(waitInterval):
+		}
+	}
+}
+
+// checkMatchingAlerts warns when no current alert matches the silence, or
+// fails with --strict-match.
+func (c *silenceAddCmd) checkMatchingAlerts(ctx context.Context, amclient *client.AlertmanagerAPI, tenant string, line int, matchers []labels.Matcher) error {
+	alerts, err := getAlerts(ctx, amclient, tenant, matchers)
 	if err != nil {
-		return tenants, fmt.Errorf("Unable to read tenant file '%s': %v", tenantFile, err)
+		return fmt.Errorf("unable to check matching alerts: %v", err)
+	}
+	if len(alerts) > 0 {
+		return nil
+	}
+	if c.strictMatch {
+		return errors.New("silence matches no current alert")
 	}
+	lineLogger(line).Warn("Silence matches no current alert", "tenant", tenant)
+	return nil
+}
 
-	fileScanner := bufio.NewScanner(readFile)
-	fileScanner.Split(bufio.ScanLines)
-	for fileScanner.Scan() {
-		tenants = append(tenants, fileScanner.Text())
+// duplicateSilence returns the ID of the silence with exactly these matchers
+// covering the period from startsAt to endsAt, if any.
+func duplicateSilence(silences models.GettableSilences, matchers []labels.Matcher, startsAt, endsAt time.Time) string {
+	typeMatchers := TypeMatchers(matchers)
+	for _, s := range silences {
+		if !MatchersEqual(s.Matchers, typeMatchers) {
+			continue
+		}
+		if !time.Time(*s.StartsAt).After(startsAt) && !time.Time(*s.EndsAt).Before(endsAt) {
+			return *s.ID
+		}
 	}
-	readFile.Close()
+	return ""
+}
 
-	return tenants, nil
+// idempotencyMarker returns the marker stored in the comment of the silence
+// with --idempotency-key, hashing the key and the matchers, or the matchers
+// and the period of the silence with 'auto'.
+func idempotencyMarker(key, period string, matchers []labels.Matcher) string {
+	if key == "auto" {
+		key = period
+	}
+	sum := sha256.Sum256([]byte(key + "\n" + MatcherKey(TypeMatchers(matchers))))
+	return "atm-key:" + hex.EncodeToString(sum[:8])
 }
 
-func setHTTPTenantHeader(httpConfig *promconfig.HTTPClientConfig, tenant, tenantHTTPHeader string) *promconfig.HTTPClientConfig {
-	if httpConfig.HTTPHeaders == nil {
-		httpConfig.HTTPHeaders = &promconfig.Headers{
-			Headers: map[string]promconfig.Header{
-				tenantHTTPHeader: {Values: []string{tenant}},
-			},
+// markedSilence returns the ID of the silence whose comment has the
+// idempotency marker, if any.
+func markedSilence(silences models.GettableSilences, marker string) string {
+	if marker == "" {
+		return ""
+	}
+	for _, s := range silences {
+		if s.Comment != nil && strings.Contains(*s.Comment, marker) {
+			return *s.ID
 		}
-	} else {
-		httpConfig.HTTPHeaders.Headers[tenantHTTPHeader] = promconfig.Header{Values: []string{tenant}}
 	}
-	return httpConfig
+	return ""
 }
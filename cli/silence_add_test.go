@@ -0,0 +1,681 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
+)
+
+func TestSilenceAddOutputStreams(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	am.fail("tenant-b", http.StatusInternalServerError)
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\ntenant-c\n")
+
+	stdout, stderr, err := runCommand(t, "silence", "add", "alertname=foo", "--tenant.file", tenantFile, "--comment", "test", "--author", "tester", "--quiet")
+	if err == nil {
+		t.Fatal("expected an error for tenant-b")
+	}
+
+	// Only the IDs of the added silences are written to stdout, so that they
+	// can be piped.
+	ids := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+	if len(ids) != 2 || !uuid.MatchString(ids[0]) || !uuid.MatchString(ids[1]) {
+		t.Fatalf("expected the 2 silence IDs on stdout, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "Unable to add silence") || !strings.Contains(stderr, "tenant-b") {
+		t.Fatalf("expected the error of tenant-b on stderr, got %q", stderr)
+	}
+
+	// Without --quiet, the progress messages go to stderr too.
+	stdout, stderr, err = runCommand(t, "silence", "add", "alertname=foo", "--tenant.file", tenantFile, "--comment", "test", "--author", "tester")
+	if err == nil {
+		t.Fatal("expected an error for tenant-b")
+	}
+	if strings.Count(stdout, "Silence added") != 2 || strings.Contains(stdout, "tenant-b") {
+		t.Fatalf("expected the added silences on stdout, got %q", stdout)
+	}
+	if !strings.Contains(stderr, "added 2, failed 1 across 3 tenants") || strings.Contains(stderr, "Silence added") {
+		t.Fatalf("expected the summary alone on stderr, got %q", stderr)
+	}
+}
+
+// addSilences runs silence add for tenant-a with --quiet, and returns the
+// matchers of the added silences.
+func addSilences(t *testing.T, am *fakeAlertmanager, args ...string) ([]string, error) {
+	t.Helper()
+	args = append([]string{"--quiet", "silence", "add", "--tenant", "tenant-a", "--comment", "test", "--author", "tester"}, args...)
+	stdout, _, err := runCommand(t, args...)
+	var matchers []string
+	for _, id := range strings.Fields(stdout) {
+		s := am.silence("tenant-a", id)
+		if s == nil {
+			t.Fatalf("silence %s not found", id)
+		}
+		matchers = append(matchers, MatcherKey(s.Matchers))
+	}
+	return matchers, err
+}
+
+func TestSilenceAddExclude(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		matchers string
+		err      string
+	}{
+		{
+			name:     "positional and exclude",
+			args:     []string{"alertname=foo", "--exclude", "env=prod"},
+			matchers: `alertname="foo",env!="prod"`,
+		},
+		{
+			name:     "several excludes",
+			args:     []string{"--exclude", "env=prod", "alertname=~Disk.*", "instance=host-1", "--exclude", `team="db ops"`},
+			matchers: `alertname=~"Disk.*",env!="prod",instance="host-1",team!="db ops"`,
+		},
+		{
+			name:     "positional negative matcher",
+			args:     []string{"alertname=foo", "env!=dev", "--exclude", "env=prod"},
+			matchers: `alertname="foo",env!="dev",env!="prod"`,
+		},
+		{
+			name: "no positive matcher",
+			args: []string{"env!=dev", "--exclude", "env=prod"},
+			err:  "at least one positive matcher is required with --exclude",
+		},
+		{
+			name: "invalid exclude",
+			args: []string{"alertname=foo", "--exclude", "env"},
+			err:  "invalid exclude 'env', expected 'name=value'",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			am := newFakeAlertmanager(t)
+			matchers, err := addSilences(t, am, tc.args...)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				if len(am.received()) > 0 {
+					t.Fatal("expected no request")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(matchers) != 1 || matchers[0] != tc.matchers {
+				t.Fatalf("expected the matchers %s, got %q", tc.matchers, matchers)
+			}
+		})
+	}
+}
+
+func TestSilenceAddSkipDuplicate(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		existing func(t *testing.T) models.Silence
+		expired  bool
+		status   string
+	}{
+		{
+			name:     "exact duplicate",
+			existing: func(t *testing.T) models.Silence { return newTestSilence(t, 2*time.Hour, "alertname=foo", "env=prod") },
+			status:   "skipped",
+		},
+		{
+			name: "duplicate with another comment",
+			existing: func(t *testing.T) models.Silence {
+				s := newTestSilence(t, 2*time.Hour, "env=prod", "alertname=foo")
+				comment := "another comment"
+				s.Comment = &comment
+				return s
+			},
+			status: "skipped",
+		},
+		{
+			name:     "other matchers",
+			existing: func(t *testing.T) models.Silence { return newTestSilence(t, 2*time.Hour, "alertname=foo", "env=dev") },
+			status:   "added",
+		},
+		{
+			name:     "regex matcher",
+			existing: func(t *testing.T) models.Silence { return newTestSilence(t, 2*time.Hour, "alertname=~foo", "env=prod") },
+			status:   "added",
+		},
+		{
+			name: "shorter period",
+			existing: func(t *testing.T) models.Silence {
+				return newTestSilence(t, 30*time.Minute, "alertname=foo", "env=prod")
+			},
+			status: "added",
+		},
+		{
+			name:     "expired",
+			existing: func(t *testing.T) models.Silence { return newTestSilence(t, 2*time.Hour, "alertname=foo", "env=prod") },
+			expired:  true,
+			status:   "added",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			am := newFakeAlertmanager(t)
+			id := am.addSilence("tenant-a", tc.existing(t))
+			if tc.expired {
+				expired := models.SilenceStatusStateExpired
+				am.silence("tenant-a", id).Status.State = &expired
+			}
+
+			stdout, _, err := runCommand(t, "--output=json", "silence", "add", "alertname=foo", "env=prod", "--duration", "1h",
+				"--tenant", "tenant-a", "--comment", "test", "--author", "tester", "--skip-duplicate")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var results []silenceResult
+			if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+				t.Fatalf("expected a JSON list, got %q (%v)", stdout, err)
+			}
+			if len(results) != 1 || results[0].Status != tc.status {
+				t.Fatalf("expected the silence to be %s, got %+v", tc.status, results)
+			}
+			posted := 0
+			for _, r := range am.received() {
+				if r.method == http.MethodPost {
+					posted++
+				}
+			}
+			switch tc.status {
+			case "skipped":
+				if results[0].SilenceID != id || posted != 0 {
+					t.Fatalf("expected the existing silence %s and no silence posted, got %s and %d posted", id, results[0].SilenceID, posted)
+				}
+			case "added":
+				if results[0].SilenceID == id || posted != 1 {
+					t.Fatalf("expected a new silence to be posted, got %s and %d posted", results[0].SilenceID, posted)
+				}
+			}
+		})
+	}
+}
+
+func TestSilenceAddSkipDuplicateTenants(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	id := am.addSilence("tenant-a", newTestSilence(t, 2*time.Hour, "alertname=foo"))
+	am.addSilence("tenant-b", newTestSilence(t, 2*time.Hour, "alertname=bar"))
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\n")
+
+	// The duplicates are looked up in the silences of each tenant.
+	stdout, _, err := runCommand(t, "--output=json", "silence", "add", "alertname=foo", "--duration", "1h",
+		"--tenant.file", tenantFile, "--comment", "test", "--author", "tester", "--skip-duplicate")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var results []silenceResult
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("expected a JSON list, got %q (%v)", stdout, err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", results)
+	}
+	if r := results[0]; r.Tenant != "tenant-a" || r.Status != "skipped" || r.SilenceID != id {
+		t.Fatalf("expected the silence of tenant-a to be skipped, got %+v", r)
+	}
+	if r := results[1]; r.Tenant != "tenant-b" || r.Status != "added" || am.silence("tenant-b", r.SilenceID) == nil {
+		t.Fatalf("expected the silence of tenant-b to be added, got %+v", r)
+	}
+}
+
+func TestSilenceAddMaxDuration(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{name: "within max duration", args: []string{"--duration", "1h", "--max-duration", "2h"}},
+		{name: "max duration in days", args: []string{"--duration", "36h", "--max-duration", "2d"}},
+		{name: "malformed", args: []string{"--duration", "1h", "--max-duration", "2 hours"}, err: "invalid max-duration '2 hours'"},
+		{name: "zero", args: []string{"--duration", "1h", "--max-duration", "0s"}, err: "invalid max-duration '0s': must be greater than 0"},
+		{name: "negative", args: []string{"--duration", "1h", "--max-duration=-1h"}, err: "invalid max-duration '-1h'"},
+		{name: "shorter than the duration", args: []string{"--duration", "3h", "--max-duration", "2h"}, err: "silence duration '3h' couldn't be greater than '2h'"},
+		{name: "shorter than the end", args: []string{"--end", "now+3h", "--max-duration", "2h"}, err: "couldn't be greater than '2h'"},
+		{name: "forced", args: []string{"--duration", "3h", "--max-duration", "2h", "--force"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			am := newFakeAlertmanager(t)
+			matchers, err := addSilences(t, am, append([]string{"alertname=foo"}, tc.args...)...)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				if len(am.received()) > 0 {
+					t.Fatal("expected no request")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(matchers) != 1 {
+				t.Fatalf("expected a silence to be added, got %q", matchers)
+			}
+		})
+	}
+}
+
+// setWaitInterval sets the time between two polls of --wait for the test.
+func setWaitInterval(t *testing.T, d time.Duration) {
+	t.Helper()
+	old := waitInterval
+	waitInterval = d
+	t.Cleanup(func() { waitInterval = old })
+}
+
+// silencePolls returns the number of times the silences were got.
+func silencePolls(am *fakeAlertmanager) int {
+	polls := 0
+	for _, r := range am.received() {
+		if r.method == http.MethodGet && strings.HasPrefix(r.path, "/api/v2/silence/") {
+			polls++
+		}
+	}
+	return polls
+}
+
+func TestSilenceAddWait(t *testing.T) {
+	setWaitInterval(t, 10*time.Millisecond)
+	am := newFakeAlertmanager(t)
+	am.pendingPolls = 3
+
+	stdout, stderr, err := runCommand(t, "--quiet", "--verbose", "silence", "add", "alertname=foo", "--tenant", "tenant-a", "--comment", "test", "--author", "tester", "--wait")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	id := strings.TrimSpace(stdout)
+	if s := am.silence("tenant-a", id); s == nil || *s.Status.State != models.SilenceStatusStateActive {
+		t.Fatalf("expected the silence %q to be active", id)
+	}
+	// The silence is got as pending 3 times, then as active.
+	if polls := silencePolls(am); polls != 4 {
+		t.Fatalf("expected 4 polls, got %d", polls)
+	}
+	if n := strings.Count(stderr, "Waiting for silence '"+id+"' for 'tenant-a' tenant to be active, state pending"); n != 3 {
+		t.Fatalf("expected 3 progress messages, got %d in %q", n, stderr)
+	}
+}
+
+func TestSilenceAddWaitTenants(t *testing.T) {
+	setWaitInterval(t, 10*time.Millisecond)
+	am := newFakeAlertmanager(t)
+	am.pendingPolls = 2
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\ntenant-c\n")
+
+	stdout, _, err := runCommand(t, "--quiet", "silence", "add", "alertname=foo", "--tenant.file", tenantFile, "--concurrency", "2", "--comment", "test", "--author", "tester", "--wait")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ids := strings.Fields(stdout)
+	if len(ids) != 3 {
+		t.Fatalf("expected 3 silences, got %q", stdout)
+	}
+	for i, tenant := range []string{"tenant-a", "tenant-b", "tenant-c"} {
+		if s := am.silence(tenant, ids[i]); s == nil || *s.Status.State != models.SilenceStatusStateActive {
+			t.Fatalf("expected the silence of %s to be active", tenant)
+		}
+	}
+	if polls := silencePolls(am); polls != 9 {
+		t.Fatalf("expected 3 polls per tenant, got %d", polls)
+	}
+}
+
+func TestSilenceAddWaitDeadline(t *testing.T) {
+	setWaitInterval(t, 10*time.Millisecond)
+	am := newFakeAlertmanager(t)
+	am.pendingPolls = 1000
+
+	start := time.Now()
+	_, _, err := runCommand(t, "--deadline", "200ms", "silence", "add", "alertname=foo", "--tenant", "tenant-a", "--comment", "test", "--author", "tester", "--wait")
+	if err == nil || !strings.Contains(err.Error(), "added but not active") {
+		t.Fatalf("expected a not active error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("expected to give up at the deadline, waited %s", elapsed)
+	}
+}
+
+func TestSilenceAddLabelFile(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	labelFile := writeTenantFile(t, "labels.properties", "# deploy labels\nenv=prod\ninstance=~host-.*\n")
+
+	// The labels are appended to the matchers of the command line.
+	matchers, err := addSilences(t, am, "alertname=foo", "--label-file", labelFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `alertname="foo",env="prod",instance=~"host-.*"`; len(matchers) != 1 || matchers[0] != expected {
+		t.Fatalf("expected the matchers %s, got %q", expected, matchers)
+	}
+
+	malformed := writeTenantFile(t, "labels.properties", "env=prod\ninstance\n")
+	if _, err := addSilences(t, am, "alertname=foo", "--label-file", malformed); err == nil || !strings.Contains(err.Error(), "invalid label at line 2") {
+		t.Fatalf("expected an invalid label error, got %v", err)
+	}
+}
+
+func TestSilenceAddCanonicalMatchers(t *testing.T) {
+	am := newFakeAlertmanager(t)
+
+	stdout, stderr, err := runCommand(t, "--quiet", "silence", "add", "env=prod", "alertname=foo", "alertname=foo", "alertname=bar",
+		"--tenant", "tenant-a", "--comment", "test", "--author", "tester")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The conflicting matchers are added anyway, with a warning.
+	if !strings.Contains(stderr, "Conflicting matchers, the silence can never match") {
+		t.Fatalf("expected a conflict warning, got %q", stderr)
+	}
+	s := am.silence("tenant-a", strings.TrimSpace(stdout))
+	if s == nil {
+		t.Fatalf("silence %q not found", stdout)
+	}
+	var posted []string
+	for _, m := range s.Matchers {
+		posted = append(posted, MatcherString(m))
+	}
+	if expected := []string{"alertname=bar", "alertname=foo", "env=prod"}; !reflect.DeepEqual(posted, expected) {
+		t.Fatalf("expected the matchers %q to be posted, got %q", expected, posted)
+	}
+}
+
+func TestSilenceAddAlertname(t *testing.T) {
+	am := newFakeAlertmanager(t)
+
+	// The alertname glob is anchored by Alertmanager, so that it matches the
+	// alertnames starting with Disk only.
+	matchers, err := addSilences(t, am, "--alertname", "Disk*", "instance=host-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `alertname=~"Disk.*",instance="host-1"`; len(matchers) != 1 || matchers[0] != expected {
+		t.Fatalf("expected the matchers %s, got %q", expected, matchers)
+	}
+	lm := labelsMatcher(*am.silence("tenant-a", "00000000-0000-0000-0000-000000000001").Matchers[0])
+	rm, err := labels.NewMatcher(lm.Type, lm.Name, lm.Value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for value, matches := range map[string]bool{"DiskFull": true, "Disk": true, "HostDiskFull": false} {
+		if rm.Matches(value) != matches {
+			t.Fatalf("expected %s to match %s: %t", lm, value, matches)
+		}
+	}
+
+	if _, err := addSilences(t, am, "--alertname", "~Disk(", "instance=host-1"); err == nil || !strings.Contains(err.Error(), "invalid --alertname") {
+		t.Fatalf("expected an invalid alertname error, got %v", err)
+	}
+}
+
+func TestSilenceAddRegexAnchored(t *testing.T) {
+	am := newFakeAlertmanager(t)
+
+	for _, tc := range []struct {
+		args     []string
+		matchers string
+	}{
+		{args: []string{"alertname=~Disk"}, matchers: `alertname=~"Disk"`},
+		{args: []string{"alertname=~Disk", "--no-regex-anchored"}, matchers: `alertname=~".*(?:Disk).*"`},
+		{args: []string{"alertname=~^Disk", "--no-regex-anchored"}, matchers: `alertname=~"(?:^Disk).*"`},
+	} {
+		matchers, err := addSilences(t, am, tc.args...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(matchers) != 1 || matchers[0] != tc.matchers {
+			t.Fatalf("expected the matchers %s for %q, got %q", tc.matchers, tc.args, matchers)
+		}
+	}
+}
+
+func TestSilenceAddIdempotencyKey(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	add := func(args ...string) string {
+		t.Helper()
+		stdout, _, err := runCommand(t, append([]string{"--quiet", "silence", "add", "--tenant", "tenant-a", "--comment", "deploy", "--author", "tester"}, args...)...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return strings.TrimSpace(stdout)
+	}
+
+	id := add("alertname=foo", "--idempotency-key", "deploy-42")
+	if s := am.silence("tenant-a", id); s == nil || !strings.Contains(*s.Comment, "atm-key:") {
+		t.Fatalf("expected the marker in the comment of %q", id)
+	}
+	// The re-run returns the silence of the first run.
+	if again := add("alertname=foo", "--idempotency-key", "deploy-42"); again != id {
+		t.Fatalf("expected the silence %s to be returned again, got %s", id, again)
+	}
+	if other := add("alertname=foo", "--idempotency-key", "deploy-43"); other == id {
+		t.Fatal("expected another key to add another silence")
+	}
+	if other := add("alertname=bar", "--idempotency-key", "deploy-42"); other == id {
+		t.Fatal("expected other matchers to add another silence")
+	}
+
+	// The auto key is derived from the matchers and the period.
+	period := []string{"--start", "2030-01-01T00:00:00Z", "--end", "2030-01-01T02:00:00Z", "--idempotency-key", "auto"}
+	auto := add(append([]string{"alertname=foo"}, period...)...)
+	if again := add(append([]string{"alertname=foo"}, period...)...); again != auto {
+		t.Fatalf("expected the silence %s to be returned again, got %s", auto, again)
+	}
+	if other := add("alertname=foo", "--start", "2030-01-01T00:00:00Z", "--end", "2030-01-01T03:00:00Z", "--idempotency-key", "auto"); other == auto {
+		t.Fatal("expected another period to add another silence")
+	}
+
+	// An expired silence doesn't count.
+	expired := models.SilenceStatusStateExpired
+	am.silence("tenant-a", id).Status.State = &expired
+	if again := add("alertname=foo", "--idempotency-key", "deploy-42"); again == id {
+		t.Fatal("expected the expired silence to be added again")
+	}
+
+	posted := 0
+	for _, r := range am.received() {
+		if r.method == http.MethodPost {
+			posted++
+		}
+	}
+	if posted != 6 {
+		t.Fatalf("expected 6 silences to be posted, got %d", posted)
+	}
+}
+
+func TestSilenceAddIdempotencyKeyAutoRelative(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	add := func(args ...string) string {
+		t.Helper()
+		stdout, _, err := runCommand(t, append([]string{"--quiet", "silence", "add", "--tenant", "tenant-a", "--comment", "deploy", "--author", "tester", "alertname=foo", "--idempotency-key=auto"}, args...)...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return strings.TrimSpace(stdout)
+	}
+
+	// Without --start, the period starts now on each run, but the key is the
+	// same.
+	id := add()
+	if again := add(); again != id {
+		t.Fatalf("expected the silence %s to be returned again, got %s", id, again)
+	}
+	if again := add("--start", "now", "--end", "+1h"); again != id {
+		t.Fatalf("expected the same period to return the silence %s, got %s", id, again)
+	}
+	if other := add("--start", "now+1h"); other == id {
+		t.Fatal("expected another start to add another silence")
+	}
+	if other := add("--duration", "2h"); other == id {
+		t.Fatal("expected another duration to add another silence")
+	}
+
+	posted := 0
+	for _, r := range am.received() {
+		if r.method == http.MethodPost {
+			posted++
+		}
+	}
+	if posted != 3 {
+		t.Fatalf("expected 3 silences to be posted, got %d", posted)
+	}
+}
+
+func TestIsAbsoluteTime(t *testing.T) {
+	for s, expected := range map[string]bool{
+		"":                     false,
+		"now":                  false,
+		"now+2h":               false,
+		"+2h":                  false,
+		"-30m":                 false,
+		"2030-01-01T00:00:00Z": true,
+		"2030-01-01T00:00:00":  true,
+	} {
+		if got := isAbsoluteTime(s); got != expected {
+			t.Fatalf("expected isAbsoluteTime(%q) to be %v", s, expected)
+		}
+	}
+}
+
+func TestSilenceAddCreatedBySuffix(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		args      []string
+		createdBy string
+	}{
+		{name: "default suffix", createdBy: "tester (via atm)"},
+		{name: "custom suffix", args: []string{"--created-by-suffix", " (via ci)"}, createdBy: "tester (via ci)"},
+		{name: "no suffix", args: []string{"--created-by-suffix", ""}, createdBy: "tester"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			am := newFakeAlertmanager(t)
+			args := append([]string{"--quiet", "silence", "add", "alertname=foo", "--tenant", "tenant-a", "--comment", "test", "--author", "tester"}, tc.args...)
+			stdout, _, err := runCommand(t, args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			s := am.silence("tenant-a", strings.TrimSpace(stdout))
+			if s == nil || *s.CreatedBy != tc.createdBy {
+				t.Fatalf("expected the silence to be created by %q, got %+v", tc.createdBy, s)
+			}
+		})
+	}
+}
+
+func TestSilenceAddEmptyAuthor(t *testing.T) {
+	am := newFakeAlertmanager(t)
+
+	for _, author := range []string{"", "  "} {
+		_, _, err := runCommand(t, "silence", "add", "alertname=foo", "--tenant", "tenant-a", "--comment", "test", "--author", author)
+		if err == nil || !strings.Contains(err.Error(), "silence author is empty: set it with --author") {
+			t.Fatalf("expected an empty author error for %q, got %v", author, err)
+		}
+	}
+	if len(am.received()) > 0 {
+		t.Fatal("expected no request")
+	}
+}
+
+func TestCommentMeta(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		comment string
+		meta    map[string]string
+		written string
+	}{
+		{
+			name:    "no meta",
+			comment: "db upgrade",
+			written: "db upgrade",
+		},
+		{
+			name:    "meta",
+			comment: "db upgrade",
+			meta:    map[string]string{"team": "db", "incident": "INC-42"},
+			written: "db upgrade\natm-meta: {\"incident\":\"INC-42\",\"team\":\"db\"}",
+		},
+		{
+			name:    "multi-line comment",
+			comment: "db upgrade\nsee the runbook\n",
+			meta:    map[string]string{"incident": "INC-42"},
+			written: "db upgrade\nsee the runbook\natm-meta: {\"incident\":\"INC-42\"}",
+		},
+		{
+			name:    "empty comment",
+			meta:    map[string]string{"incident": "INC-42"},
+			written: "atm-meta: {\"incident\":\"INC-42\"}",
+		},
+		{
+			name:    "special characters",
+			comment: "db upgrade",
+			meta:    map[string]string{"url": "https://example.com/?a=1&b=\"2\"", "note": "line\nbreak"},
+			written: "db upgrade\natm-meta: {\"note\":\"line\\nbreak\",\"url\":\"https://example.com/?a=1\\u0026b=\\\"2\\\"\"}",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			written := withCommentMeta(tc.comment, tc.meta)
+			if written != tc.written {
+				t.Fatalf("expected the comment %q, got %q", tc.written, written)
+			}
+			comment, meta := parseCommentMeta(written)
+			if expected := strings.TrimRight(tc.comment, "\n"); len(tc.meta) > 0 && comment != expected {
+				t.Fatalf("expected the human comment %q, got %q", expected, comment)
+			}
+			if len(tc.meta) > 0 && !reflect.DeepEqual(meta, tc.meta) {
+				t.Fatalf("expected the meta %q, got %q", tc.meta, meta)
+			}
+			if len(tc.meta) == 0 && meta != nil {
+				t.Fatalf("expected no meta, got %q", meta)
+			}
+		})
+	}
+}
+
+func TestParseCommentMetaNotMeta(t *testing.T) {
+	// The comments without a valid last metadata line are kept as is.
+	for _, comment := range []string{
+		"",
+		"db upgrade",
+		"atm-meta: {\"incident\":\"INC-42\"}\ndb upgrade",
+		"db upgrade\natm-meta: not json",
+		"db upgrade\natm-meta: {\"incident\":42}",
+		"db upgrade\n atm-meta: {\"incident\":\"INC-42\"}",
+	} {
+		if human, meta := parseCommentMeta(comment); human != comment || meta != nil {
+			t.Fatalf("expected %q to be kept without meta, got %q and %q", comment, human, meta)
+		}
+	}
+}
+
+func TestSilenceAddMetaEmptyKey(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	if _, err := addSilences(t, am, "alertname=foo", "--meta", "=INC-42"); err == nil || !strings.Contains(err.Error(), "the key is empty") {
+		t.Fatalf("expected an empty key error, got %v", err)
+	}
+}
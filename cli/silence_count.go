@@ -0,0 +1,127 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/prometheus/alertmanager/api/v2/models"
+)
+
+type silenceCountCmd struct {
+	matchers []string
+	states   []string
+	tenantFlags
+}
+
+const silenceCountHelp = `Count alertmanager silences
+
+  The number of active silences of all the authors is printed, for status
+  pages and dashboards:
+
+  atm silence count --tenant.file examples/tenants.conf --state pending
+
+	This statement prints the number of pending silences of each tenant of
+	the file, as one 'tenant count' line each.
+
+  The silences are counted by --state, active by default, and can be
+  filtered by matchers like with query. --output=json prints a {"count":N}
+  object, or an array of {"tenant":"x","count":N} objects with several
+  tenants. A zero count isn't an error.
+`
+
+func configureSilenceCountCmd(cc *kingpin.CmdClause) {
+	var (
+		c        = &silenceCountCmd{}
+		countCmd = cc.Command("count", silenceCountHelp)
+	)
+	c.tenantFlags.configure(countCmd)
+	countCmd.Flag("state", "Only count the silences in this state, can be repeated").Default(models.SilenceStatusStateActive).EnumsVar(&c.states, models.SilenceStatusStateActive, models.SilenceStatusStatePending, models.SilenceStatusStateExpired)
+	countCmd.Arg("matcher-groups", "Query filter").StringsVar(&c.matchers)
+	countCmd.Action(execWithTimeout(c.count))
+}
+
+func (c *silenceCountCmd) count(ctx context.Context, _ *kingpin.ParseContext) error {
+	if _, err := parseMatchers(c.matchers); err != nil {
+		return err
+	}
+	states := make(map[string]bool, len(c.states))
+	for _, state := range c.states {
+		states[state] = true
+	}
+
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	var (
+		counts  = make([]tenantCountJSON, 0, len(tenants))
+		skipped []string
+	)
+	failed := 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	params := silence.NewGetSilencesParams().WithContext(ctx).WithTimeout(timeout).WithFilter(c.matchers)
+	for i, t := range tenants {
+		if ctx.Err() != nil {
+			skipped = tenants[i:]
+			break
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		var getOk *silence.GetSilencesOK
+		err = withRetry(ctx, "count silences"+forTenant(t), func() (err error) {
+			getOk, err = amclient.Silence.GetSilences(params)
+			return err
+		})
+		if err != nil {
+			if !c.multiTenant() {
+				return fmt.Errorf("Unable to count silences%s: %v", forTenant(t), err)
+			}
+			failed++
+			logger.Error("Unable to count silences", "tenant", t, "err", err)
+			recordFailure(t, err)
+			continue
+		}
+		n := 0
+		for _, s := range getOk.Payload {
+			if states[*s.Status.State] {
+				n++
+			}
+		}
+		counts = append(counts, tenantCountJSON{Tenant: t, Count: n})
+	}
+	if err := printSilenceCounts(counts, c.multiTenant()); err != nil {
+		return err
+	}
+
+	c.printSummary("counted", len(counts), failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(len(counts), fmt.Errorf("failed to count silences for %d tenant(s)", failed))
+	}
+	return nil
+}
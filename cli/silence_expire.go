@@ -0,0 +1,224 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-openapi/strfmt"
+	"golang.org/x/term"
+
+	"github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+)
+
+type silenceExpireCmd struct {
+	ids   []string
+	yes   bool
+	force bool
+	matchFlags
+	tenantFlags
+}
+
+const silenceExpireHelp = `Expire alertmanager silences
+
+  Silences are expired either by their IDs, or by matchers when using the
+  --match flag:
+
+  atm silence expire --match alertname=foo --match node=bar
+
+	This statement will expire all silences which matchers are exactly
+	alertname=foo and node=bar. With --all-matching, the silences having
+	other matchers in addition to these ones are expired too.
+
+  When the silences are selected by matchers or on several tenants, the
+  number of silences and some of them are printed, and a confirmation is
+  asked before expiring them. Use --yes to skip it. When stdin is not a
+  terminal, --force is required instead.
+`
+
+// confirmSample is the number of silences shown when asking for confirmation.
+const confirmSample = 5
+
+// expirePlan holds the silences to expire for a given tenant.
+type expirePlan struct {
+	tenant   string
+	ids      []string
+	amclient *client.AlertmanagerAPI
+}
+
+func configureSilenceExpireCmd(cc *kingpin.CmdClause) {
+	var (
+		c         = &silenceExpireCmd{}
+		expireCmd = cc.Command("expire", silenceExpireHelp)
+	)
+	c.tenantFlags.configure(expireCmd)
+	c.matchFlags.configure(expireCmd, "expire")
+	expireCmd.Flag("yes", "Expire the silences without asking for confirmation").Short('y').BoolVar(&c.yes)
+	expireCmd.Flag("force", "Expire the silences without confirmation when stdin is not a terminal").BoolVar(&c.force)
+	expireCmd.Arg("silence-ids", "Ids of silences to expire").StringsVar(&c.ids)
+	expireCmd.Action(execWithTimeout(c.expire))
+}
+
+func (c *silenceExpireCmd) expire(ctx context.Context, _ *kingpin.ParseContext) error {
+	matchers, err := c.matchers(c.ids)
+	if err != nil {
+		return err
+	}
+
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	var (
+		results []silenceResult
+		plans   []expirePlan
+		skipped []string
+	)
+	expired, failed, selected := 0, 0, 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	for i, t := range tenants {
+		if ctx.Err() != nil {
+			skipped = tenants[i:]
+			break
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		ids := c.ids
+		if len(matchers) > 0 {
+			ids, err = c.matchingSilenceIDs(ctx, amclient, matchers, false)
+			if err != nil {
+				failed++
+				results = append(results, silenceResult{Tenant: t, Status: "failed", Error: err.Error()})
+				logger.Error("Unable to query silences", "tenant", t, "err", err)
+				recordFailure(t, err)
+				continue
+			}
+			logger.Info("Silences will be expired", "tenant", t, "count", len(ids), "ids", strings.Join(ids, ","))
+		}
+		selected += len(ids)
+		plans = append(plans, expirePlan{tenant: t, ids: ids, amclient: amclient})
+	}
+
+	if selected > 0 && (len(matchers) > 0 || len(tenants) > 1) && ctx.Err() == nil {
+		if err := c.confirm(plans, selected); err != nil {
+			return err
+		}
+	}
+
+	for i, p := range plans {
+		if ctx.Err() != nil {
+			var remaining []string
+			for _, p := range plans[i:] {
+				remaining = append(remaining, p.tenant)
+			}
+			skipped = append(remaining, skipped...)
+			break
+		}
+		t, amclient := p.tenant, p.amclient
+		for _, id := range p.ids {
+			params := silence.NewDeleteSilenceParams().WithContext(ctx).WithTimeout(timeout)
+			params.SilenceID = strfmt.UUID(id)
+			err := withRetry(ctx, fmt.Sprintf("expire silence '%s'%s", id, forTenant(t)), func() error {
+				_, err := amclient.Silence.DeleteSilence(params)
+				return err
+			})
+			if err != nil {
+				failed++
+				var notFound *silence.DeleteSilenceNotFound
+				if errors.As(err, &notFound) {
+					results = append(results, silenceResult{Tenant: t, SilenceID: id, Status: "not found"})
+					logger.Error("Silence not found", "tenant", t, "id", id)
+					recordFailure(t, fmt.Errorf("silence '%s' not found", id))
+					continue
+				}
+				results = append(results, silenceResult{Tenant: t, SilenceID: id, Status: "failed", Error: err.Error()})
+				logger.Error("Unable to expire silence", "tenant", t, "id", id, "err", err)
+				recordFailure(t, err)
+				continue
+			}
+			expired++
+			results = append(results, silenceResult{Tenant: t, SilenceID: id, Status: "expired"})
+			printResult(id, "Silence expired%s: %s\n", forTenant(t), id)
+		}
+	}
+
+	if err := printResults(results); err != nil {
+		return err
+	}
+
+	c.printSummary("expired", expired, failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(expired, fmt.Errorf("failed to expire %d silence(s)", failed))
+	}
+	return nil
+}
+
+// confirm asks on stderr for the confirmation to expire the selected
+// silences. It's skipped with --yes, and when stdin is not a terminal --force
+// is required.
+func (c *silenceExpireCmd) confirm(plans []expirePlan, selected int) error {
+	if c.yes {
+		return nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		if c.force {
+			return nil
+		}
+		return fmt.Errorf("refusing to expire %d silence(s) without confirmation, stdin is not a terminal: use --force", selected)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d silence(s) will be expired:\n", selected)
+	shown := 0
+	for _, p := range plans {
+		for _, id := range p.ids {
+			if shown == confirmSample {
+				break
+			}
+			fmt.Fprintf(os.Stderr, "  %s%s\n", id, forTenant(p.tenant))
+			shown++
+		}
+	}
+	if selected > shown {
+		fmt.Fprintf(os.Stderr, "  ... and %d more\n", selected-shown)
+	}
+	fmt.Fprint(os.Stderr, "Expire them? [y/N] ")
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return nil
+	}
+	return errors.New("expire aborted")
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/prometheus/alertmanager/api/v2/models"
+)
+
+type silenceExportCmd struct {
+	expired bool
+	tenantFlags
+}
+
+const silenceExportHelp = `Export alertmanager silences
+
+  The silences are written as a single document grouping them by tenant,
+  which can be read back by silence import. The document is YAML, or JSON
+  with --output=json.
+
+  atm silence export --tenant.file tenants.conf > silences.yml
+
+	This statement will export the active and pending silences of all the
+	tenants of the file. Use --expired to export the expired ones too.
+`
+
+func configureSilenceExportCmd(cc *kingpin.CmdClause) {
+	var (
+		c         = &silenceExportCmd{}
+		exportCmd = cc.Command("export", silenceExportHelp)
+	)
+	c.tenantFlags.configure(exportCmd)
+	exportCmd.Flag("expired", "Also export the expired silences").BoolVar(&c.expired)
+	exportCmd.Action(execWithTimeout(c.export))
+}
+
+// exportedTenant holds the silences of a tenant in an export document.
+type exportedTenant struct {
+	Tenant   string            `json:"tenant" yaml:"tenant"`
+	Silences []exportedSilence `json:"silences" yaml:"silences"`
+}
+
+// exportedSilence holds what is needed to recreate a silence. The matchers
+// use the syntax of silence add.
+type exportedSilence struct {
+	ID        string    `json:"id" yaml:"id"`
+	Matchers  []string  `json:"matchers" yaml:"matchers"`
+	StartsAt  time.Time `json:"startsAt" yaml:"startsAt"`
+	EndsAt    time.Time `json:"endsAt" yaml:"endsAt"`
+	CreatedBy string    `json:"createdBy" yaml:"createdBy"`
+	Comment   string    `json:"comment" yaml:"comment"`
+}
+
+func (c *silenceExportCmd) export(ctx context.Context, _ *kingpin.ParseContext) error {
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	var skipped []string
+	failed := 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	doc := make([]exportedTenant, 0, len(tenants))
+	for i, t := range tenants {
+		if ctx.Err() != nil {
+			skipped = tenants[i:]
+			break
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		params := silence.NewGetSilencesParams().WithContext(ctx).WithTimeout(timeout)
+		var getOk *silence.GetSilencesOK
+		err = withRetry(ctx, "query silences"+forTenant(t), func() (err error) {
+			getOk, err = amclient.Silence.GetSilences(params)
+			return err
+		})
+		if err != nil {
+			if !c.multiTenant() {
+				return fmt.Errorf("Unable to query silences%s: %v", forTenant(t), err)
+			}
+			failed++
+			logger.Error("Unable to query silences", "tenant", t, "err", err)
+			recordFailure(t, err)
+			continue
+		}
+
+		et := exportedTenant{Tenant: t, Silences: []exportedSilence{}}
+		for _, s := range getOk.Payload {
+			if !c.expired && *s.Status.State == models.SilenceStatusStateExpired {
+				continue
+			}
+			et.Silences = append(et.Silences, newExportedSilence(s))
+		}
+		doc = append(doc, et)
+	}
+
+	if output == "json" {
+		if err := printJSON(doc); err != nil {
+			return err
+		}
+	} else {
+		b, err := yaml.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		if _, err := stdout.Write(b); err != nil {
+			return err
+		}
+	}
+
+	c.printSummary("exported", len(doc), failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(len(doc), fmt.Errorf("failed to export silences for %d tenant(s)", failed))
+	}
+	return nil
+}
+
+func newExportedSilence(s *models.GettableSilence) exportedSilence {
+	matchers := make([]string, 0, len(s.Matchers))
+	for _, m := range s.Matchers {
+		matchers = append(matchers, MatcherString(m))
+	}
+	return exportedSilence{
+		ID:        *s.ID,
+		Matchers:  matchers,
+		StartsAt:  time.Time(*s.StartsAt).UTC(),
+		EndsAt:    time.Time(*s.EndsAt).UTC(),
+		CreatedBy: *s.CreatedBy,
+		Comment:   *s.Comment,
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-openapi/strfmt"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/models"
+)
+
+type silenceExtendCmd struct {
+	by          string
+	maxDuration string
+	reactivate  bool
+	ids         []string
+	md          model.Duration
+	matchFlags
+	tenantFlags
+}
+
+const silenceExtendHelp = `Extend alertmanager silences
+
+  The end of the silences is pushed back by the given duration:
+
+  atm silence extend <id> --by 1h
+
+	This statement will make the silence end 1h later than it currently does.
+
+  atm silence extend --match alertname=foo --by 1h --tenant.file tenants
+
+	This statement will extend the silences matching alertname=foo for all
+	the tenants of the file.
+
+  Expired silences can't be extended, unless --reactivate is set. They are
+  then restarted from now for the given duration.
+`
+
+func configureSilenceExtendCmd(cc *kingpin.CmdClause) {
+	var (
+		c         = &silenceExtendCmd{}
+		extendCmd = cc.Command("extend", silenceExtendHelp)
+	)
+	c.tenantFlags.configure(extendCmd)
+	c.matchFlags.configure(extendCmd, "extend")
+	extendCmd.Flag("by", "Duration to add to the end of the silence").Required().StringVar(&c.by)
+	extendCmd.Flag("max-duration", "Max Duration of silence, from its start").Default("12h").StringVar(&c.maxDuration)
+	extendCmd.Flag("reactivate", "Restart expired silences from now for the given duration").BoolVar(&c.reactivate)
+	extendCmd.Arg("silence-ids", "Ids of silences to extend").StringsVar(&c.ids)
+	extendCmd.Action(execWithTimeout(c.extend))
+}
+
+func (c *silenceExtendCmd) extend(ctx context.Context, _ *kingpin.ParseContext) error {
+	by, err := model.ParseDuration(c.by)
+	if err != nil {
+		return err
+	}
+	if by == 0 {
+		return errors.New("extension duration must be greater than 0")
+	}
+	if c.md, err = parseMaxDuration(c.maxDuration); err != nil {
+		return err
+	}
+
+	matchers, err := c.matchers(c.ids)
+	if err != nil {
+		return err
+	}
+
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	var (
+		results []silenceResult
+		skipped []string
+	)
+	extended, failed := 0, 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	for i, t := range tenants {
+		if ctx.Err() != nil {
+			skipped = tenants[i:]
+			break
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		ids := c.ids
+		if len(matchers) > 0 {
+			ids, err = c.matchingSilenceIDs(ctx, amclient, matchers, c.reactivate)
+			if err != nil {
+				failed++
+				results = append(results, silenceResult{Tenant: t, Status: "failed", Error: err.Error()})
+				logger.Error("Unable to query silences", "tenant", t, "err", err)
+				recordFailure(t, err)
+				continue
+			}
+			logger.Info("Silences will be extended", "tenant", t, "count", len(ids), "ids", strings.Join(ids, ","))
+		}
+
+		for _, id := range ids {
+			newID, err := c.extendSilence(ctx, amclient, t, id, time.Duration(by))
+			if err != nil {
+				failed++
+				results = append(results, silenceResult{Tenant: t, SilenceID: id, Status: "failed", Error: err.Error()})
+				logger.Error("Unable to extend silence", "tenant", t, "id", id, "err", err)
+				recordFailure(t, err)
+				continue
+			}
+			extended++
+			results = append(results, silenceResult{Tenant: t, SilenceID: newID, Status: "extended"})
+			printResult(newID, "Silence extended%s: %s\n", forTenant(t), newID)
+		}
+	}
+
+	if err := printResults(results); err != nil {
+		return err
+	}
+
+	c.printSummary("extended", extended, failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(extended, fmt.Errorf("failed to extend %d silence(s)", failed))
+	}
+	return nil
+}
+
+// extendSilence pushes back the end of the silence and returns its ID after
+// the update.
+func (c *silenceExtendCmd) extendSilence(ctx context.Context, amclient *client.AlertmanagerAPI, tenant, id string, by time.Duration) (string, error) {
+	gs, err := getSilence(ctx, amclient, tenant, id)
+	if err != nil {
+		return "", err
+	}
+	sil := gs.Silence
+
+	startsAt := time.Time(*sil.StartsAt)
+	endsAt := time.Time(*sil.EndsAt).Add(by)
+	if *gs.Status.State == models.SilenceStatusStateExpired {
+		if !c.reactivate {
+			return "", errors.New("silence is expired, use --reactivate to restart it")
+		}
+		startsAt = time.Now().UTC()
+		endsAt = startsAt.Add(by)
+		start := strfmt.DateTime(startsAt)
+		sil.StartsAt = &start
+	}
+
+	duration := model.Duration(endsAt.Sub(startsAt))
+	if limit, source := c.tenantMaxDuration(tenant, c.md); duration > limit {
+		return "", fmt.Errorf("silence duration '%s' couldn't be greater than '%s' set by %s", duration, limit, source)
+	}
+	end := strfmt.DateTime(endsAt)
+	sil.EndsAt = &end
+
+	return postSilence(ctx, amclient, tenant, id, sil)
+}
@@ -0,0 +1,65 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSilenceExtendTenantMaxDuration(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	ids := map[string]string{}
+	for _, tenant := range []string{"tenant-a", "tenant-b"} {
+		ids[tenant] = am.addSilence(tenant, newTestSilence(t, time.Hour, "alertname=foo"))
+	}
+	tenantFile := writeTenantFile(t, "tenants.yml", "- tenant: tenant-a\n  max_duration: 2h\n- tenant: tenant-b\n")
+
+	// The silence of tenant-a would last 3h, beyond its own max_duration,
+	// while tenant-b is only limited by --max-duration.
+	_, _, err := runCommand(t, "silence", "extend", "--match", "alertname=foo", "--by", "2h", "--tenant.file", tenantFile)
+	if err == nil || !strings.Contains(err.Error(), "failed to extend 1 silence(s)") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s := am.silence("tenant-a", ids["tenant-a"]); time.Until(time.Time(*s.EndsAt)) > time.Hour {
+		t.Fatalf("expected the silence of tenant-a not to be extended, it ends at %s", s.EndsAt)
+	}
+	extended := 0
+	for _, r := range am.received() {
+		if r.method == "POST" {
+			if r.tenant != "tenant-b" {
+				t.Fatalf("expected only the silence of tenant-b to be extended, got a request for %q", r.tenant)
+			}
+			extended++
+		}
+	}
+	if extended != 1 {
+		t.Fatalf("expected a single silence to be extended, got %d", extended)
+	}
+}
+
+func TestSilenceExtendTenantMaxDurationError(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	id := am.addSilence("tenant-a", newTestSilence(t, time.Hour, "alertname=foo"))
+	tenantFile := writeTenantFile(t, "tenants.yml", "- tenant: tenant-a\n  max_duration: 2h\n")
+
+	_, stderr, err := runCommand(t, "silence", "extend", id, "--by", "2h", "--tenant.file", tenantFile)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if expected := "couldn't be greater than '2h' set by the max_duration of 'tenant-a' tenant"; !strings.Contains(stderr, expected) {
+		t.Fatalf("expected %q in the logs, got %q", expected, stderr)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-openapi/strfmt"
+
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/cli/format"
+)
+
+type silenceGetCmd struct {
+	id string
+	tenantFlags
+}
+
+func configureSilenceGetCmd(cc *kingpin.CmdClause) {
+	var (
+		c      = &silenceGetCmd{}
+		getCmd = cc.Command("get", "Get an alertmanager silence by its ID")
+	)
+	c.tenantFlags.configure(getCmd)
+	getCmd.Arg("silence-id", "Id of the silence to get").Required().StringVar(&c.id)
+	getCmd.Action(execWithTimeout(c.get))
+}
+
+func (c *silenceGetCmd) get(ctx context.Context, _ *kingpin.ParseContext) error {
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	var (
+		silences []models.GettableSilence
+		results  []tenantSilences
+		skipped  []string
+		got      int
+		failed   int
+	)
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	for i, t := range tenants {
+		if ctx.Err() != nil {
+			skipped = tenants[i:]
+			break
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		params := silence.NewGetSilenceParams().WithContext(ctx).WithTimeout(timeout)
+		params.SilenceID = strfmt.UUID(c.id)
+		var getOk *silence.GetSilenceOK
+		err = withRetry(ctx, fmt.Sprintf("get silence '%s'%s", c.id, forTenant(t)), func() (err error) {
+			getOk, err = amclient.Silence.GetSilence(params)
+			return err
+		})
+		if err != nil {
+			var notFound *silence.GetSilenceNotFound
+			if errors.As(err, &notFound) {
+				err = fmt.Errorf("Silence '%s' not found%s", c.id, forTenant(t))
+			} else {
+				err = fmt.Errorf("Unable to get silence '%s'%s: %v", c.id, forTenant(t), err)
+			}
+			if !c.multiTenant() {
+				return err
+			}
+			failed++
+			logger.Error("Unable to get silence", "tenant", t, "err", err)
+			recordFailure(t, err)
+			continue
+		}
+		got++
+
+		if output != "simple" {
+			results = append(results, tenantSilences{tenant: t, silences: models.GettableSilences{getOk.Payload}})
+			silences = append(silences, *getOk.Payload)
+			continue
+		}
+		if err := printSilence(t, getOk.Payload); err != nil {
+			return err
+		}
+	}
+
+	if err := printTenantSilences(silences, results); err != nil {
+		return err
+	}
+
+	c.printSummary("got", got, failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(got, fmt.Errorf("failed to get silence '%s' for %d tenant(s)", c.id, failed))
+	}
+	return nil
+}
+
+// printTenantSilences writes the silences got from the tenants, unless the
+// output is simple as they are written as they are got.
+func printTenantSilences(silences []models.GettableSilence, results []tenantSilences) error {
+	switch output {
+	case "simple":
+		return nil
+	case "csv", "template":
+		p := newSilencePrinter(false, false, false, nil)
+		for _, r := range results {
+			if err := p.print(r); err != nil {
+				return err
+			}
+		}
+		return p.close()
+	}
+	formatter, found := format.Formatters[output]
+	if !found {
+		return errors.New("unknown output formatter")
+	}
+	return formatter.FormatSilences(silences)
+}
+
+// printSilence writes all the fields of a silence, one per line.
+func printSilence(tenant string, s *models.GettableSilence) error {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	if tenant != "" {
+		fmt.Fprintf(w, "Tenant:\t%s\n", tenant)
+	}
+	fmt.Fprintf(w, "ID:\t%s\n", *s.ID)
+	fmt.Fprintf(w, "Matchers:\t%s\n", formatMatchers(s.Matchers))
+	fmt.Fprintf(w, "Starts At:\t%s\n", format.FormatDate(*s.StartsAt))
+	fmt.Fprintf(w, "Ends At:\t%s\n", format.FormatDate(*s.EndsAt))
+	fmt.Fprintf(w, "Updated At:\t%s\n", format.FormatDate(*s.UpdatedAt))
+	fmt.Fprintf(w, "Created By:\t%s\n", *s.CreatedBy)
+	fmt.Fprintf(w, "Comment:\t%s\n", *s.Comment)
+	fmt.Fprintf(w, "State:\t%s\n", colorState(*s.Status.State))
+	return w.Flush()
+}
@@ -0,0 +1,217 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-openapi/strfmt"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/prometheus/alertmanager/api/v2/models"
+)
+
+type silenceImportCmd struct {
+	file         string
+	dryRun       bool
+	skipExisting bool
+	remapTenants map[string]string
+	tenantFlags
+}
+
+const silenceImportHelp = `Import alertmanager silences
+
+  The silences of a document written by silence export, in YAML or JSON, are
+  added to their tenant. The silences which already ended are skipped.
+
+  atm silence import silences.yml --remap-tenant tenant-a=tenant-c
+
+	This statement will add the silences of tenant-a to tenant-c, and the
+	silences of the other tenants to their own tenant.
+`
+
+func configureSilenceImportCmd(cc *kingpin.CmdClause) {
+	var (
+		c         = &silenceImportCmd{remapTenants: map[string]string{}}
+		importCmd = cc.Command("import", silenceImportHelp)
+	)
+	c.tenantFlags.configureHeader(importCmd)
+	importCmd.Flag("dry-run", "Validate the silences and print their payload without adding them").BoolVar(&c.dryRun)
+	importCmd.Flag("skip-existing", "Skip the silences whose matchers are the ones of an active or pending silence of the tenant").BoolVar(&c.skipExisting)
+	importCmd.Flag("remap-tenant", "Import the silences of a tenant to another one, as old=new").PlaceHolder("old=new").StringMapVar(&c.remapTenants)
+	importCmd.Arg("file", "Export file to import").Required().ExistingFileVar(&c.file)
+	importCmd.Action(execWithTimeout(c.importSilences))
+}
+
+func (c *silenceImportCmd) importSilences(ctx context.Context, _ *kingpin.ParseContext) error {
+	b, err := os.ReadFile(c.file)
+	if err != nil {
+		return fmt.Errorf("Unable to read export file '%s': %v", c.file, err)
+	}
+	// JSON is valid YAML, a single decoder reads both formats.
+	var doc []exportedTenant
+	if err := yaml.UnmarshalStrict(b, &doc); err != nil {
+		return fmt.Errorf("Unable to read export file '%s': %v", c.file, err)
+	}
+
+	var (
+		results []silenceResult
+		skipped []string
+	)
+	imported, failed := 0, 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for i, et := range doc {
+		if ctx.Err() != nil {
+			for _, et := range doc[i:] {
+				skipped = append(skipped, et.Tenant)
+			}
+			break
+		}
+		t := et.Tenant
+		if to, ok := c.remapTenants[t]; ok {
+			t = to
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		var existing models.GettableSilences
+		if c.skipExisting {
+			existing, err = activeSilences(ctx, amclient, t)
+			if err != nil {
+				failed += len(et.Silences)
+				results = append(results, silenceResult{Tenant: t, Status: "failed", Error: err.Error()})
+				logger.Error("Unable to query silences", "tenant", t, "err", err)
+				recordFailure(t, err)
+				continue
+			}
+		}
+
+		for _, es := range et.Silences {
+			sr := silenceResult{Tenant: t, Source: es.ID}
+			if !es.EndsAt.After(now) {
+				sr.Status = "skipped"
+				results = append(results, sr)
+				logger.Info("Silence already ended, skipped", "tenant", t, "source", es.ID)
+				continue
+			}
+
+			matchers, err := parseMatchers(es.Matchers)
+			if err == nil && len(matchers) < 1 {
+				err = errors.New("no matchers specified")
+			}
+			if err == nil {
+				err = validateMatchers(matchers)
+			}
+			if err != nil {
+				failed++
+				sr.Status, sr.Error = "failed", err.Error()
+				results = append(results, sr)
+				logger.Error("Invalid silence", "tenant", t, "source", es.ID, "err", err)
+				recordFailure(t, err)
+				continue
+			}
+
+			if c.skipExisting && hasSilence(existing, matchers) {
+				sr.Status = "skipped"
+				results = append(results, sr)
+				logger.Info("Silence already exists, skipped", "tenant", t, "source", es.ID)
+				continue
+			}
+
+			start := strfmt.DateTime(es.StartsAt)
+			end := strfmt.DateTime(es.EndsAt)
+			createdBy, comment := es.CreatedBy, es.Comment
+			ps := &models.PostableSilence{
+				Silence: models.Silence{
+					Matchers:  TypeMatchers(matchers),
+					StartsAt:  &start,
+					EndsAt:    &end,
+					CreatedBy: &createdBy,
+					Comment:   &comment,
+				},
+			}
+
+			if c.dryRun {
+				if err := ps.Validate(strfmt.Default); err != nil {
+					failed++
+					sr.Status, sr.Error = "failed", err.Error()
+					results = append(results, sr)
+					logger.Error("Invalid silence", "tenant", t, "source", es.ID, "err", err)
+					recordFailure(t, err)
+					continue
+				}
+				payload, err := json.Marshal(ps)
+				if err != nil {
+					return err
+				}
+				imported++
+				sr.Status, sr.Silence = "validated", ps
+				results = append(results, sr)
+				printf("Silence validated%s: %s: %s\n", forTenant(t), es.ID, payload)
+				continue
+			}
+
+			params := silence.NewPostSilencesParams().WithContext(ctx).WithTimeout(timeout).WithSilence(ps)
+			var postOk *silence.PostSilencesOK
+			err = withRetry(ctx, fmt.Sprintf("import silence '%s'%s", es.ID, forTenant(t)), func() (err error) {
+				postOk, err = amclient.Silence.PostSilences(params)
+				return err
+			})
+			if err != nil {
+				failed++
+				sr.Status, sr.Error = "failed", err.Error()
+				results = append(results, sr)
+				logger.Error("Unable to import silence", "tenant", t, "source", es.ID, "err", err)
+				recordFailure(t, err)
+				continue
+			}
+			imported++
+			sr.Status, sr.SilenceID = "imported", postOk.Payload.SilenceID
+			results = append(results, sr)
+			printResult(sr.SilenceID, "Silence imported%s: %s -> %s\n", forTenant(t), es.ID, sr.SilenceID)
+		}
+	}
+
+	if err := printResults(results); err != nil {
+		return err
+	}
+
+	action := "imported"
+	if c.dryRun {
+		action = "validated"
+	}
+	if c.summary && !quiet {
+		fmt.Fprintf(os.Stderr, "%s %d, failed %d across %d tenants\n", action, imported, failed, len(doc))
+	}
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(imported, fmt.Errorf("failed to import %d silence(s)", failed))
+	}
+	return nil
+}
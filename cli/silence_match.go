@@ -0,0 +1,99 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
+)
+
+// matchFlags holds the flags selecting silences by matchers instead of IDs.
+type matchFlags struct {
+	match       []string
+	allMatching bool
+}
+
+func (f *matchFlags) configure(cmd *kingpin.CmdClause, action string) {
+	cmd.Flag("match", "Select the silences to "+action+" with these matchers instead of by IDs").Short('m').StringsVar(&f.match)
+	cmd.Flag("all-matching", "Also select the silences having more matchers than the given ones").BoolVar(&f.allMatching)
+}
+
+// matchers returns the parsed --match matchers, nil when the silences are
+// selected by IDs.
+func (f *matchFlags) matchers(ids []string) ([]labels.Matcher, error) {
+	if len(ids) > 0 && len(f.match) > 0 {
+		return nil, errors.New("silence IDs and --match are mutually exclusive")
+	}
+	if len(f.match) > 0 {
+		return parseMatchers(f.match)
+	}
+	if len(ids) < 1 {
+		return nil, errors.New("no silence IDs specified")
+	}
+	return nil, nil
+}
+
+// matchingSilenceIDs returns the IDs of the silences whose matchers are
+// exactly the given ones, or include them all with --all-matching. Expired
+// silences are skipped unless withExpired is set.
+func (f *matchFlags) matchingSilenceIDs(ctx context.Context, amclient *client.AlertmanagerAPI, matchers []labels.Matcher, withExpired bool) ([]string, error) {
+	params := silence.NewGetSilencesParams().WithContext(ctx).WithTimeout(timeout).WithFilter(f.match)
+	var getOk *silence.GetSilencesOK
+	err := withRetry(ctx, "query silences", func() (err error) {
+		getOk, err = amclient.Silence.GetSilences(params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ids          []string
+		typeMatchers = TypeMatchers(matchers)
+	)
+	for _, s := range getOk.Payload {
+		if !withExpired && *s.Status.State == models.SilenceStatusStateExpired {
+			continue
+		}
+		if f.allMatching && hasMatchers(s.Matchers, matchers) || !f.allMatching && MatchersEqual(s.Matchers, typeMatchers) {
+			ids = append(ids, *s.ID)
+		}
+	}
+	return ids, nil
+}
+
+// hasMatchers reports whether all the matchers are part of the silence ones.
+func hasMatchers(silenceMatchers models.Matchers, matchers []labels.Matcher) bool {
+	for _, m := range matchers {
+		found := false
+		for _, sm := range silenceMatchers {
+			lm := labelsMatcher(*sm)
+			if lm.Type == m.Type && lm.Name == m.Name && lm.Value == m.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,72 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMatchingSilenceIDs(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	ids := map[string]string{}
+	for name, matchers := range map[string][]string{
+		"exact":     {"a=1", "b=2"},
+		"reordered": {"b=2", "a=1"},
+		"more":      {"a=1", "b=2", "c=3"},
+		"other":     {"a=1", "b=3"},
+	} {
+		ids[am.addSilence("tenant-a", newTestSilence(t, time.Hour, matchers...))] = name
+	}
+	// A silence with a duplicate matcher has as many matchers as the
+	// requested ones, without being the same silence.
+	dup := newTestSilence(t, time.Hour, "a=1")
+	dup.Matchers = append(dup.Matchers, dup.Matchers[0])
+	ids[am.addSilence("tenant-a", dup)] = "duplicate"
+
+	tf := testTenantFlags()
+	amclient := testClient(t, &tf, "tenant-a")
+	for _, tc := range []struct {
+		match       []string
+		allMatching bool
+		expected    []string
+	}{
+		{match: []string{"a=1", "b=2"}, expected: []string{"exact", "reordered"}},
+		{match: []string{"a=1", "b=2"}, allMatching: true, expected: []string{"exact", "more", "reordered"}},
+		{match: []string{"a=1", "a=1"}, expected: []string{"duplicate"}},
+		{match: []string{"a=1"}, expected: nil},
+		{match: []string{"a=1"}, allMatching: true, expected: []string{"duplicate", "exact", "more", "other", "reordered"}},
+	} {
+		f := matchFlags{match: tc.match, allMatching: tc.allMatching}
+		matchers, err := f.matchers(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := f.matchingSilenceIDs(context.Background(), amclient, matchers, false)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var names []string
+		for _, id := range got {
+			names = append(names, ids[id])
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Fatalf("expected %q to select %q with all-matching %v, got %q", tc.match, tc.expected, tc.allMatching, names)
+		}
+	}
+}
@@ -0,0 +1,732 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/cli/format"
+)
+
+type silenceQueryCmd struct {
+	matchers        []string
+	since           string
+	until           string
+	states          []string
+	count           bool
+	author          string
+	createdBySuffix string
+	allAuthors      bool
+	limit           int
+	sort            string
+	sortSet         bool
+	fields          string
+	showMeta        bool
+	tenantFlags
+}
+
+const silenceQueryHelp = `Query alertmanager silences
+
+  The non-option section of arguments constructs a list of "Matcher Groups"
+  that will be used to filter the query server side. The following examples
+  will attempt to show this behaviour in action:
+
+  atm silence query alertname=foo node=bar
+
+	This query will match all silences with the alertname=foo and node=bar
+	label value pairs set.
+
+  atm silence query foo --tenant.file examples/tenants.conf
+
+	If alertname is omitted and the first argument does not contain a '=' or a
+	'=~' then it will be assumed to be the value of the alertname pair. When
+	querying several tenants, each row is prefixed with the tenant it came from.
+
+  atm silence query --since now-24h --state expired
+
+	The API filters the silences only by matchers, --since, --until and
+	--state are applied on the returned silences. This query will match the
+	silences which expired during the last 24 hours.
+
+  atm silence query --author 'oncall-*'
+
+	Only the silences created by the current user are shown by default.
+	--author selects another author, matched case insensitively, where '*'
+	and '?' are wildcards, with or without the --created-by-suffix added by
+	silence add, ' (via atm)' by default. Use --all-authors to show the
+	silences of everyone.
+
+  atm silence query --tenant.file examples/tenants.conf --sort=-starts
+
+	The silences are sorted by --sort, their end by default so that the
+	silences about to expire come first. The key is one of starts, ends,
+	author, state or tenant, prefixed with '-' for the descending order
+	and given as --sort=-starts. The silences of each tenant are sorted as
+	they are printed, unless --sort is given, which sorts the silences of
+	all the tenants, breaking the ties by tenant.
+
+  atm silence query --fields id,endsAt,createdBy,comment
+
+	The table output only has the given fields, in their order, instead of
+	the tenant when querying several tenants, id, matchers, startsAt,
+	endsAt, state and createdBy.
+
+  atm silence query --show-meta --fields id,comment
+
+	The metadata added by silence add --meta are parsed from the comment,
+	and shown apart from its human part: as the meta field of the table,
+	which is added if missing, a meta column of the csv output, and a meta
+	object of the JSON and YAML silences. Templates always have them as .Meta.
+
+  atm silence query --all-authors --tenant.file examples/tenants.conf --limit 100 | head
+
+	The silences are printed as soon as each tenant is queried rather than
+	once all of them are, except with --sort, --count and the extended and
+	yaml outputs. With --limit, atm stops once that many silences are
+	printed, without querying the next tenants.
+`
+
+func configureSilenceQueryCmd(cc *kingpin.CmdClause) {
+	var (
+		c        = &silenceQueryCmd{}
+		queryCmd = cc.Command("query", silenceQueryHelp)
+	)
+	c.tenantFlags.configure(queryCmd)
+	queryCmd.Flag("since", "Only show the silences ending after this time. RFC3339 format 2006-01-02T15:04:05-07:00, or relative to now like now-2h").StringVar(&c.since)
+	queryCmd.Flag("until", "Only show the silences starting before this time. RFC3339 format 2006-01-02T15:04:05-07:00, or relative to now like now+2h").StringVar(&c.until)
+	queryCmd.Flag("state", "Only show the silences in this state, can be repeated").EnumsVar(&c.states, models.SilenceStatusStateActive, models.SilenceStatusStatePending, models.SilenceStatusStateExpired)
+	queryCmd.Flag("author", "Only show the silences created by this author, '*' and '?' being wildcards").Short('a').Default(username()).StringVar(&c.author)
+	queryCmd.Flag("created-by-suffix", "Suffix of the CreatedBy field tolerated after the --author, as set by silence add. Empty to match the author exactly").Default(" (via atm)").StringVar(&c.createdBySuffix)
+	queryCmd.Flag("all-authors", "Show the silences of all the authors").BoolVar(&c.allAuthors)
+	queryCmd.Flag("sort", "Sort the silences by starts, ends, author, state or tenant, prefixed with '-' for the descending order").Default("ends").IsSetByUser(&c.sortSet).EnumVar(&c.sort, silenceSortKeys...)
+	queryCmd.Flag("fields", "Comma separated fields of the table output, in their order: "+fieldNames(silenceFields)).PlaceHolder("id,endsAt,comment").StringVar(&c.fields)
+	queryCmd.Flag("show-meta", "Show the metadata added by silence add --meta").BoolVar(&c.showMeta)
+	queryCmd.Flag("limit", "Maximum number of silences to print, 0 means no limit").Default("0").IntVar(&c.limit)
+	queryCmd.Flag("count", "Only print the number of matching silences, per tenant").BoolVar(&c.count)
+	queryCmd.Arg("matcher-groups", "Query filter").StringsVar(&c.matchers)
+	queryCmd.Action(execWithTimeout(c.query))
+}
+
+// tenantSilences holds the silences returned for a given tenant.
+type tenantSilences struct {
+	tenant   string
+	silences models.GettableSilences
+}
+
+func (c *silenceQueryCmd) query(ctx context.Context, _ *kingpin.ParseContext) error {
+	if _, err := parseMatchers(c.matchers); err != nil {
+		return err
+	}
+
+	filter, err := c.silenceFilter()
+	if err != nil {
+		return err
+	}
+	fields, err := parseFields(c.fields, silenceFields, defaultSilenceFields, c.multiTenant())
+	if err != nil {
+		return err
+	}
+	if c.showMeta && !hasField(fields, "meta") {
+		fields = append(fields, tableField{"meta", "Meta", false})
+	}
+
+	silenceParams := silence.NewGetSilencesParams().WithContext(ctx).WithTimeout(timeout).WithFilter(c.matchers)
+
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	failed, queried, printed := 0, 0, 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	var (
+		skipped []string
+		// With --sort, the silences of all the tenants are sorted before
+		// being printed.
+		sorted []tenantSilences
+	)
+	limited := c.limit > 0 && !c.count
+	p := newSilencePrinter(c.multiTenant(), c.count, c.showMeta, fields)
+	for i, t := range tenants {
+		if limited && !c.sortSet && printed >= c.limit {
+			break
+		}
+		if ctx.Err() != nil {
+			skipped = tenants[i:]
+			break
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		var getOk *silence.GetSilencesOK
+		err = withRetry(ctx, "query silences"+forTenant(t), func() (err error) {
+			getOk, err = amclient.Silence.GetSilences(silenceParams)
+			return err
+		})
+		if err != nil {
+			if !c.multiTenant() {
+				return fmt.Errorf("Unable to query silences%s: %v", forTenant(t), err)
+			}
+			failed++
+			logger.Error("Unable to query silences", "tenant", t, "err", err)
+			recordFailure(t, err)
+			continue
+		}
+		queried++
+		silences := filterSilences(getOk.Payload, filter)
+		if c.sortSet {
+			sorted = append(sorted, tenantSilences{tenant: t, silences: silences})
+			continue
+		}
+		less := silenceLess(c.sort)
+		sort.SliceStable(silences, func(i, j int) bool {
+			return less(t, silences[i], t, silences[j])
+		})
+		if limited && len(silences) > c.limit-printed {
+			silences = silences[:c.limit-printed]
+		}
+		printed += len(silences)
+		if err := p.print(tenantSilences{tenant: t, silences: silences}); err != nil {
+			return err
+		}
+	}
+	limit := 0
+	if limited {
+		limit = c.limit
+	}
+	for _, r := range sortSilences(sorted, c.sort, limit) {
+		if err := p.print(r); err != nil {
+			return err
+		}
+	}
+	if err := p.close(); err != nil {
+		return err
+	}
+
+	c.printSummary("queried", queried, failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(queried, fmt.Errorf("failed to query silences for %d tenant(s)", failed))
+	}
+	return nil
+}
+
+// silenceSortKeys are the keys of --sort.
+var silenceSortKeys = []string{"starts", "ends", "author", "state", "tenant", "-starts", "-ends", "-author", "-state", "-tenant"}
+
+// silenceStateOrder is the order of the states when sorting by state.
+var silenceStateOrder = map[string]int{
+	models.SilenceStatusStateActive:  0,
+	models.SilenceStatusStatePending: 1,
+	models.SilenceStatusStateExpired: 2,
+}
+
+// silenceLess returns the function ordering the silences of the tenants by
+// the --sort key, the ties being broken by tenant.
+func silenceLess(key string) func(ta string, a *models.GettableSilence, tb string, b *models.GettableSilence) bool {
+	desc := strings.HasPrefix(key, "-")
+	key = strings.TrimPrefix(key, "-")
+	return func(ta string, a *models.GettableSilence, tb string, b *models.GettableSilence) bool {
+		var c int
+		switch key {
+		case "starts":
+			c = time.Time(*a.StartsAt).Compare(time.Time(*b.StartsAt))
+		case "ends":
+			c = time.Time(*a.EndsAt).Compare(time.Time(*b.EndsAt))
+		case "author":
+			c = strings.Compare(strings.ToLower(*a.CreatedBy), strings.ToLower(*b.CreatedBy))
+		case "state":
+			c = silenceStateOrder[*a.Status.State] - silenceStateOrder[*b.Status.State]
+		case "tenant":
+			c = strings.Compare(ta, tb)
+		}
+		if desc {
+			c = -c
+		}
+		if c == 0 {
+			return ta < tb
+		}
+		return c < 0
+	}
+}
+
+// sortSilences sorts the silences of all the tenants by the --sort key,
+// keeping the first limit ones unless it's 0. The sorted silences are grouped
+// by tenant where consecutive, followed by the tenants without silences.
+// Equal silences are kept in their original order.
+func sortSilences(results []tenantSilences, key string, limit int) []tenantSilences {
+	type entry struct {
+		tenant  string
+		silence *models.GettableSilence
+	}
+	var (
+		entries []entry
+		empty   []tenantSilences
+	)
+	for _, r := range results {
+		if len(r.silences) == 0 {
+			empty = append(empty, r)
+		}
+		for _, s := range r.silences {
+			entries = append(entries, entry{tenant: r.tenant, silence: s})
+		}
+	}
+	less := silenceLess(key)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return less(entries[i].tenant, entries[i].silence, entries[j].tenant, entries[j].silence)
+	})
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	var sorted []tenantSilences
+	for i, e := range entries {
+		if i == 0 || e.tenant != entries[i-1].tenant {
+			sorted = append(sorted, tenantSilences{tenant: e.tenant})
+		}
+		last := &sorted[len(sorted)-1]
+		last.silences = append(last.silences, e.silence)
+	}
+	return append(sorted, empty...)
+}
+
+// silenceFilter returns the function selecting the silences from the time
+// window and state flags.
+func (c *silenceQueryCmd) silenceFilter() (func(*models.GettableSilence) bool, error) {
+	var since, until time.Time
+	now := time.Now().UTC()
+	if c.since != "" {
+		t, err := parseTime(c.since, now, now, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --since: %v", err)
+		}
+		since = t
+	}
+	if c.until != "" {
+		t, err := parseTime(c.until, now, now, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --until: %v", err)
+		}
+		until = t
+	}
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		return nil, errors.New("--until is before --since")
+	}
+
+	var author *regexp.Regexp
+	if !c.allAuthors && c.author != "" {
+		author = authorRegexp(c.author, c.createdBySuffix)
+	}
+
+	return func(s *models.GettableSilence) bool {
+		if !since.IsZero() && !time.Time(*s.EndsAt).After(since) {
+			return false
+		}
+		if !until.IsZero() && !time.Time(*s.StartsAt).Before(until) {
+			return false
+		}
+		if author != nil && !author.MatchString(*s.CreatedBy) {
+			return false
+		}
+		if len(c.states) == 0 {
+			return true
+		}
+		for _, state := range c.states {
+			if *s.Status.State == state {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// authorRegexp returns the case insensitive regexp matching the whole author
+// pattern, where '*' matches any sequence of characters and '?' any character.
+// The author may be followed by the suffix, added by --created-by-suffix of
+// silence add.
+func authorRegexp(pattern, suffix string) *regexp.Regexp {
+	re := `(?i)^(?:` + globRegex(pattern) + `)`
+	if suffix != "" {
+		re += `(?:` + regexp.QuoteMeta(suffix) + `)?`
+	}
+	return regexp.MustCompile(re + `$`)
+}
+
+// filterSilences returns the silences selected by keep.
+func filterSilences(silences models.GettableSilences, keep func(*models.GettableSilence) bool) models.GettableSilences {
+	filtered := make(models.GettableSilences, 0, len(silences))
+	for _, s := range silences {
+		if keep(s) {
+			filtered = append(filtered, s)
+		}
+	}
+	return filtered
+}
+
+// tenantSilencesJSON is the JSON representation of tenantSilences.
+type tenantSilencesJSON struct {
+	Tenant   string        `json:"tenant"`
+	Silences []interface{} `json:"silences"`
+}
+
+// streamFlushRows is the number of rows of the table written before flushing
+// it while streaming.
+const streamFlushRows = 100
+
+// silencePrinter prints the silences of each tenant as they are queried, so
+// that large listings are not held in memory. The results are buffered only
+// with --count and the extended and yaml outputs.
+type silencePrinter struct {
+	withTenant bool
+	buffered   bool
+	results    []tenantSilences
+	count      bool
+	meta       bool
+
+	table  *tabwriter.Writer
+	fields []tableField
+	rows   int
+	csv    *csv.Writer
+	json   *jsonArrayWriter
+	// yaml holds the JSON elements written as a YAML list once done.
+	yaml []interface{}
+}
+
+// silenceFields are the fields of the silences in the table output.
+var silenceFields = []tableField{
+	{"tenant", "Tenant", false},
+	{"id", "ID", false},
+	{"matchers", "Matchers", false},
+	{"startsAt", "Starts At", false},
+	{"endsAt", "Ends At", false},
+	{"updatedAt", "Updated At", false},
+	{"state", "State", true},
+	{"createdBy", "Created By", false},
+	{"comment", "Comment", false},
+	{"meta", "Meta", false},
+}
+
+// defaultSilenceFields are the fields of the table output without --fields.
+var defaultSilenceFields = []string{"id", "matchers", "startsAt", "endsAt", "state", "createdBy"}
+
+// silenceFieldValue returns the value of the field of a silence of the tenant
+// in the table output.
+func silenceFieldValue(name, tenant string, s *models.GettableSilence) string {
+	switch name {
+	case "tenant":
+		return tenant
+	case "id":
+		return *s.ID
+	case "matchers":
+		return formatMatchers(s.Matchers)
+	case "startsAt":
+		return format.FormatDate(*s.StartsAt)
+	case "endsAt":
+		return format.FormatDate(*s.EndsAt)
+	case "updatedAt":
+		if s.UpdatedAt == nil {
+			return ""
+		}
+		return format.FormatDate(*s.UpdatedAt)
+	case "state":
+		return colorState(*s.Status.State)
+	case "createdBy":
+		return *s.CreatedBy
+	case "comment":
+		// The metadata line is only shown by the meta field.
+		comment, _ := parseCommentMeta(*s.Comment)
+		return comment
+	case "meta":
+		_, meta := parseCommentMeta(*s.Comment)
+		return formatMeta(meta)
+	}
+	return ""
+}
+
+// formatMeta returns the comma separated 'key=value' metadata, sorted by key.
+func formatMeta(meta map[string]string) string {
+	pairs := make([]string, 0, len(meta))
+	for k, v := range meta {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// silenceWithMeta returns the JSON object of the silence with its metadata,
+// parsed from its comment, as the meta object.
+func silenceWithMeta(s *models.GettableSilence) (map[string]interface{}, error) {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, err
+	}
+	comment, meta := parseCommentMeta(*s.Comment)
+	obj["comment"] = comment
+	if meta == nil {
+		meta = map[string]string{}
+	}
+	obj["meta"] = meta
+	return obj, nil
+}
+
+// newSilencePrinter returns the printer of the --output format, prefixing the
+// silences with their tenant when withTenant is set, or printing their number
+// only with count. The table has the given fields, showMeta adds the metadata
+// of the comments to the csv, JSON and YAML outputs.
+func newSilencePrinter(withTenant, count, showMeta bool, fields []tableField) *silencePrinter {
+	p := &silencePrinter{withTenant: withTenant, count: count, meta: showMeta, fields: fields}
+	switch {
+	case count:
+		p.buffered = true
+	case output == "simple":
+		p.table = tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		writeTableHeader(p.table, p.fields)
+	case output == "csv":
+		p.csv = csv.NewWriter(stdout)
+		header := []string{"tenant", "id", "matchers", "startsAt", "endsAt", "state", "createdBy", "comment"}
+		if p.meta {
+			header = append(header, "meta")
+		}
+		p.csv.Write(header)
+	case output == "json":
+		p.json = &jsonArrayWriter{w: stdout}
+	case output == "yaml":
+		p.yaml = []interface{}{}
+	case output == "template":
+	default:
+		p.buffered = true
+	}
+	return p
+}
+
+// print writes the silences of a tenant, or buffers them.
+func (p *silencePrinter) print(r tenantSilences) error {
+	switch {
+	case p.buffered:
+		p.results = append(p.results, r)
+	case p.table != nil:
+		for _, s := range r.silences {
+			for _, f := range p.fields {
+				fmt.Fprintf(p.table, "%s\t", silenceFieldValue(f.name, r.tenant, s))
+			}
+			fmt.Fprintln(p.table)
+			p.rows++
+			if p.rows%streamFlushRows == 0 {
+				if err := p.table.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+	case p.csv != nil:
+		for _, s := range r.silences {
+			record := []string{
+				r.tenant,
+				*s.ID,
+				formatMatchers(s.Matchers),
+				time.Time(*s.StartsAt).UTC().Format(time.RFC3339),
+				time.Time(*s.EndsAt).UTC().Format(time.RFC3339),
+				*s.Status.State,
+				*s.CreatedBy,
+				*s.Comment,
+			}
+			if p.meta {
+				comment, meta := parseCommentMeta(*s.Comment)
+				record[7] = comment
+				if len(meta) > 0 {
+					b, _ := json.Marshal(meta)
+					record = append(record, string(b))
+				} else {
+					record = append(record, "")
+				}
+			}
+			p.csv.Write(record)
+		}
+		p.csv.Flush()
+		return p.csv.Error()
+	case p.json != nil:
+		elems, err := p.jsonElements(r)
+		if err != nil {
+			return err
+		}
+		for _, e := range elems {
+			if err := p.json.write(e); err != nil {
+				return err
+			}
+		}
+	case p.yaml != nil:
+		elems, err := p.jsonElements(r)
+		if err != nil {
+			return err
+		}
+		p.yaml = append(p.yaml, elems...)
+	default:
+		for _, s := range r.silences {
+			_, meta := parseCommentMeta(*s.Comment)
+			data := silenceTemplateData{Tenant: r.tenant, SilenceID: *s.ID, Status: *s.Status.State, Silence: s, Meta: meta}
+			if err := executeTemplate(data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// jsonElements returns the elements of the JSON array of the silences of a
+// tenant: the silences, or an object with the tenant and its silences when
+// withTenant is set.
+func (p *silencePrinter) jsonElements(r tenantSilences) ([]interface{}, error) {
+	silences := make([]interface{}, 0, len(r.silences))
+	for _, s := range r.silences {
+		if !p.meta {
+			silences = append(silences, s)
+			continue
+		}
+		obj, err := silenceWithMeta(s)
+		if err != nil {
+			return nil, err
+		}
+		silences = append(silences, obj)
+	}
+	if p.withTenant {
+		return []interface{}{tenantSilencesJSON{Tenant: r.tenant, Silences: silences}}, nil
+	}
+	return silences, nil
+}
+
+// close ends the output, printing the buffered results.
+func (p *silencePrinter) close() error {
+	switch {
+	case p.count:
+		counts := make([]tenantCountJSON, 0, len(p.results))
+		for _, r := range p.results {
+			counts = append(counts, tenantCountJSON{Tenant: r.tenant, Count: len(r.silences)})
+		}
+		return printSilenceCounts(counts, p.withTenant)
+	case p.buffered:
+		formatter, found := format.Formatters[output]
+		if !found {
+			return errors.New("unknown output formatter")
+		}
+		var silences []models.GettableSilence
+		for _, r := range p.results {
+			for _, s := range r.silences {
+				silences = append(silences, *s)
+			}
+		}
+		return formatter.FormatSilences(silences)
+	case p.table != nil:
+		return p.table.Flush()
+	case p.json != nil:
+		return p.json.close()
+	case p.yaml != nil:
+		return writeYAML(stdout, p.yaml)
+	}
+	return nil
+}
+
+// jsonArrayWriter writes a JSON array one element at a time.
+type jsonArrayWriter struct {
+	w io.Writer
+	n int
+}
+
+func (a *jsonArrayWriter) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sep := ",\n"
+	if a.n == 0 {
+		sep = "[\n"
+	}
+	a.n++
+	_, err = fmt.Fprintf(a.w, "%s%s", sep, b)
+	return err
+}
+
+func (a *jsonArrayWriter) close() error {
+	if a.n == 0 {
+		_, err := fmt.Fprintln(a.w, "[]")
+		return err
+	}
+	_, err := fmt.Fprintln(a.w, "\n]")
+	return err
+}
+
+// tenantCountJSON is the JSON representation of the number of silences of a
+// tenant.
+type tenantCountJSON struct {
+	Tenant string `json:"tenant"`
+	Count  int    `json:"count"`
+}
+
+// countJSON is the JSON representation of the number of silences without a
+// tenant.
+type countJSON struct {
+	Count int `json:"count"`
+}
+
+// printSilenceCounts writes the number of silences of each tenant, prefixed
+// with the tenant when withTenant is set. Without it, the structured outputs
+// are a single count object like the text output is a single integer.
+func printSilenceCounts(counts []tenantCountJSON, withTenant bool) error {
+	if structuredOutput() {
+		if !withTenant && len(counts) == 1 {
+			return printStructured(countJSON{Count: counts[0].Count})
+		}
+		return printStructured(counts)
+	}
+	for _, c := range counts {
+		if withTenant {
+			fmt.Fprintf(stdout, "%s %d\n", c.Tenant, c.Count)
+		} else {
+			fmt.Fprintf(stdout, "%d\n", c.Count)
+		}
+	}
+	return nil
+}
+
+// formatMatchers renders the matchers using the Prometheus label syntax.
+func formatMatchers(matchers models.Matchers) string {
+	output := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		output = append(output, MatcherString(m))
+	}
+	return strings.Join(output, " ")
+}
@@ -0,0 +1,466 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-openapi/strfmt"
+
+	"github.com/prometheus/alertmanager/api/v2/models"
+)
+
+// windowSilence returns a silence named after its window, relative to now,
+// in the state it would have.
+func windowSilence(id string, start, end time.Duration, createdBy string) *models.GettableSilence {
+	now := time.Now().UTC()
+	var (
+		startsAt = strfmt.DateTime(now.Add(start))
+		endsAt   = strfmt.DateTime(now.Add(end))
+		state    = models.SilenceStatusStateActive
+	)
+	switch {
+	case end < 0:
+		state = models.SilenceStatusStateExpired
+	case start > 0:
+		state = models.SilenceStatusStatePending
+	}
+	return &models.GettableSilence{
+		ID:      &id,
+		Status:  &models.SilenceStatus{State: &state},
+		Silence: models.Silence{StartsAt: &startsAt, EndsAt: &endsAt, CreatedBy: &createdBy},
+	}
+}
+
+func TestSilenceFilterWindow(t *testing.T) {
+	silences := models.GettableSilences{
+		windowSilence("expired-last-week", -8*24*time.Hour, -7*24*time.Hour, "tester"),
+		windowSilence("expired-yesterday", -30*time.Hour, -20*time.Hour, "tester"),
+		windowSilence("active", -time.Hour, time.Hour, "tester"),
+		windowSilence("pending-tomorrow", 20*time.Hour, 30*time.Hour, "tester"),
+		windowSilence("pending-next-week", 7*24*time.Hour, 8*24*time.Hour, "tester"),
+	}
+
+	for _, tc := range []struct {
+		name   string
+		cmd    silenceQueryCmd
+		ids    []string
+		hasErr bool
+	}{
+		{
+			name: "no filter",
+			ids:  []string{"expired-last-week", "expired-yesterday", "active", "pending-tomorrow", "pending-next-week"},
+		},
+		{
+			name: "since",
+			cmd:  silenceQueryCmd{since: "now-2d"},
+			ids:  []string{"expired-yesterday", "active", "pending-tomorrow", "pending-next-week"},
+		},
+		{
+			name: "until",
+			cmd:  silenceQueryCmd{until: "now+2d"},
+			ids:  []string{"expired-last-week", "expired-yesterday", "active", "pending-tomorrow"},
+		},
+		{
+			name: "since and until",
+			cmd:  silenceQueryCmd{since: "now-2d", until: "now+2d"},
+			ids:  []string{"expired-yesterday", "active", "pending-tomorrow"},
+		},
+		{
+			name: "RFC3339 window",
+			cmd: silenceQueryCmd{
+				since: time.Now().Add(-2 * time.Hour).Format(time.RFC3339),
+				until: time.Now().Add(2 * time.Hour).Format(time.RFC3339),
+			},
+			ids: []string{"active"},
+		},
+		{
+			name: "expired since",
+			cmd:  silenceQueryCmd{since: "now-2d", states: []string{models.SilenceStatusStateExpired}},
+			ids:  []string{"expired-yesterday"},
+		},
+		{
+			name: "active and pending",
+			cmd:  silenceQueryCmd{states: []string{models.SilenceStatusStateActive, models.SilenceStatusStatePending}},
+			ids:  []string{"active", "pending-tomorrow", "pending-next-week"},
+		},
+		{
+			name:   "until before since",
+			cmd:    silenceQueryCmd{since: "now", until: "now-1h"},
+			hasErr: true,
+		},
+		{
+			name:   "invalid since",
+			cmd:    silenceQueryCmd{since: "yesterday"},
+			hasErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := tc.cmd
+			c.allAuthors = true
+			keep, err := c.silenceFilter()
+			if tc.hasErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			ids := []string{}
+			for _, s := range filterSilences(silences, keep) {
+				ids = append(ids, *s.ID)
+			}
+			if !reflect.DeepEqual(ids, tc.ids) {
+				t.Fatalf("expected %q, got %q", tc.ids, ids)
+			}
+		})
+	}
+}
+
+func TestSilenceQueryAuthor(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	for _, createdBy := range []string{"alice", "Alice (via atm)", "alice (via ci)", "alice-bot", "oncall-bob (via atm)", "OnCall-Carol", "bob"} {
+		createdBy := createdBy
+		sil := newTestSilence(t, time.Hour, "alertname=foo")
+		sil.CreatedBy = &createdBy
+		am.addSilence("tenant-a", sil)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		args   []string
+		author []string
+	}{
+		{name: "exact", args: []string{"--author", "alice"}, author: []string{"alice", "Alice (via atm)"}},
+		{name: "case insensitive", args: []string{"--author", "ALICE"}, author: []string{"alice", "Alice (via atm)"}},
+		{name: "glob", args: []string{"--author", "oncall-*"}, author: []string{"oncall-bob (via atm)", "OnCall-Carol"}},
+		{name: "single character glob", args: []string{"--author", "b?b"}, author: []string{"bob"}},
+		{name: "suffix", args: []string{"--author", "alice", "--created-by-suffix", " (via ci)"}, author: []string{"alice", "alice (via ci)"}},
+		{name: "no suffix", args: []string{"--author", "alice", "--created-by-suffix", ""}, author: []string{"alice"}},
+		{name: "suffix in the pattern", args: []string{"--author", "* (via atm)"}, author: []string{"Alice (via atm)", "oncall-bob (via atm)"}},
+		{name: "all authors", args: []string{"--author", "alice", "--all-authors"}, author: []string{"alice", "Alice (via atm)", "alice (via ci)", "alice-bot", "oncall-bob (via atm)", "OnCall-Carol", "bob"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			args := append([]string{"--output=json", "silence", "query", "--tenant", "tenant-a", "--sort=author"}, tc.args...)
+			stdout, _, err := runCommand(t, args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var silences []models.GettableSilence
+			if err := json.Unmarshal([]byte(stdout), &silences); err != nil {
+				t.Fatalf("expected a JSON list, got %q (%v)", stdout, err)
+			}
+			authors := []string{}
+			for _, s := range silences {
+				authors = append(authors, *s.CreatedBy)
+			}
+			sort.Strings(authors)
+			expected := append([]string{}, tc.author...)
+			sort.Strings(expected)
+			if !reflect.DeepEqual(authors, expected) {
+				t.Fatalf("expected the silences of %q, got %q", expected, authors)
+			}
+		})
+	}
+}
+
+func TestSilenceQueryLarge(t *testing.T) {
+	const perTenant = 2000
+	am := newFakeAlertmanager(t)
+	tenants := []string{"tenant-a", "tenant-b", "tenant-c"}
+	for _, tenant := range tenants {
+		for i := 0; i < perTenant; i++ {
+			am.addSilence(tenant, newTestSilence(t, time.Duration(i+1)*time.Minute, "alertname=foo", fmt.Sprintf("instance=host-%d", i)))
+		}
+	}
+	tenantFile := writeTenantFile(t, "tenants.txt", strings.Join(tenants, "\n"))
+
+	queries := func() int {
+		n := 0
+		for _, r := range am.received() {
+			if r.path == "/api/v2/silences" {
+				n++
+			}
+		}
+		return n
+	}
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		silences int
+		queries  int
+	}{
+		{name: "no limit", silences: len(tenants) * perTenant, queries: 3},
+		{name: "limit within the first tenant", args: []string{"--limit", "10"}, silences: 10, queries: 1},
+		{name: "limit across tenants", args: []string{"--limit", "2500"}, silences: 2500, queries: 2},
+		{name: "limit with sort", args: []string{"--limit", "10", "--sort=-ends"}, silences: 10, queries: 3},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			before := queries()
+			args := append([]string{"--output=json", "silence", "query", "--all-authors", "--tenant.file", tenantFile}, tc.args...)
+			stdout, _, err := runCommand(t, args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var results []struct {
+				Tenant   string                   `json:"tenant"`
+				Silences []map[string]interface{} `json:"silences"`
+			}
+			if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+				t.Fatalf("expected a JSON list, got %d bytes (%v)", len(stdout), err)
+			}
+			silences := 0
+			for _, r := range results {
+				silences += len(r.Silences)
+			}
+			if silences != tc.silences {
+				t.Fatalf("expected %d silences, got %d", tc.silences, silences)
+			}
+			if n := queries() - before; n != tc.queries {
+				t.Fatalf("expected %d tenants to be queried, got %d", tc.queries, n)
+			}
+		})
+	}
+
+	// The table has a line per silence after the header.
+	stdout, _, err := runCommand(t, "silence", "query", "--all-authors", "--tenant.file", tenantFile, "--limit", "25")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lines := strings.Count(stdout, "\n"); lines != 26 {
+		t.Fatalf("expected a header and 25 silences, got %d lines", lines)
+	}
+}
+
+func TestSortSilences(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newSilence := func(id string, starts, ends int, author, state string) *models.GettableSilence {
+		startsAt := strfmt.DateTime(base.Add(time.Duration(starts) * time.Hour))
+		endsAt := strfmt.DateTime(base.Add(time.Duration(ends) * time.Hour))
+		return &models.GettableSilence{
+			ID:      &id,
+			Status:  &models.SilenceStatus{State: &state},
+			Silence: models.Silence{StartsAt: &startsAt, EndsAt: &endsAt, CreatedBy: &author},
+		}
+	}
+	const (
+		active  = models.SilenceStatusStateActive
+		pending = models.SilenceStatusStatePending
+		expired = models.SilenceStatusStateExpired
+	)
+	results := []tenantSilences{
+		{tenant: "tenant-b", silences: models.GettableSilences{
+			newSilence("b1", 0, 3, "bob", active),
+			newSilence("b2", 1, 2, "Alice", pending),
+			newSilence("b3", 0, 2, "carol", active),
+		}},
+		{tenant: "tenant-c"},
+		{tenant: "tenant-a", silences: models.GettableSilences{
+			newSilence("a1", 0, 2, "alice", expired),
+			newSilence("a2", 2, 4, "bob", active),
+		}},
+	}
+
+	for _, tc := range []struct {
+		key   string
+		limit int
+		order string
+	}{
+		// The ties are broken by tenant, then kept in their original order.
+		{key: "ends", order: "tenant-a:a1 tenant-b:b2,b3,b1 tenant-a:a2 tenant-c:"},
+		{key: "-ends", order: "tenant-a:a2 tenant-b:b1 tenant-a:a1 tenant-b:b2,b3 tenant-c:"},
+		{key: "starts", order: "tenant-a:a1 tenant-b:b1,b3,b2 tenant-a:a2 tenant-c:"},
+		{key: "author", order: "tenant-a:a1 tenant-b:b2 tenant-a:a2 tenant-b:b1,b3 tenant-c:"},
+		{key: "state", order: "tenant-a:a2 tenant-b:b1,b3,b2 tenant-a:a1 tenant-c:"},
+		{key: "-tenant", order: "tenant-b:b1,b2,b3 tenant-a:a1,a2 tenant-c:"},
+		{key: "ends", limit: 3, order: "tenant-a:a1 tenant-b:b2,b3 tenant-c:"},
+	} {
+		t.Run(fmt.Sprintf("%s limit %d", tc.key, tc.limit), func(t *testing.T) {
+			var order []string
+			for _, r := range sortSilences(results, tc.key, tc.limit) {
+				var ids []string
+				for _, s := range r.silences {
+					ids = append(ids, *s.ID)
+				}
+				order = append(order, r.tenant+":"+strings.Join(ids, ","))
+			}
+			if s := strings.Join(order, " "); s != tc.order {
+				t.Fatalf("expected %s, got %s", tc.order, s)
+			}
+		})
+	}
+}
+
+func TestSilenceQueryShowMeta(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	if _, err := addSilences(t, am, "alertname=foo", "--meta", "incident=INC-42", "--meta", "team=db"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := addSilences(t, am, "alertname=bar"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query := func(t *testing.T, output string, args ...string) string {
+		t.Helper()
+		args = append([]string{"--output=" + output, "silence", "query", "--tenant", "tenant-a", "--all-authors"}, args...)
+		stdout, _, err := runCommand(t, args...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return stdout
+	}
+
+	t.Run("json", func(t *testing.T) {
+		var silences []struct {
+			Comment string            `json:"comment"`
+			Meta    map[string]string `json:"meta"`
+		}
+		stdout := query(t, "json", "--show-meta")
+		if err := json.Unmarshal([]byte(stdout), &silences); err != nil {
+			t.Fatalf("expected a JSON list, got %q (%v)", stdout, err)
+		}
+		if len(silences) != 2 {
+			t.Fatalf("expected 2 silences, got %d", len(silences))
+		}
+		var metas []map[string]string
+		for _, s := range silences {
+			if s.Comment != "test" {
+				t.Fatalf("expected the human comment only, got %q", s.Comment)
+			}
+			metas = append(metas, s.Meta)
+		}
+		sort.Slice(metas, func(i, j int) bool { return len(metas[i]) > len(metas[j]) })
+		// The silences without metadata have an empty meta object.
+		if expected := []map[string]string{{"incident": "INC-42", "team": "db"}, {}}; !reflect.DeepEqual(metas, expected) {
+			t.Fatalf("expected the meta %v, got %v", expected, metas)
+		}
+	})
+
+	t.Run("json without --show-meta", func(t *testing.T) {
+		stdout := query(t, "json")
+		if !strings.Contains(stdout, `test\natm-meta: {\"incident\":\"INC-42\",\"team\":\"db\"}`) || strings.Contains(stdout, `"meta"`) {
+			t.Fatalf("expected the comment unchanged, got %q", stdout)
+		}
+	})
+
+	t.Run("table", func(t *testing.T) {
+		stdout := query(t, "simple", "--show-meta", "--fields", "comment")
+		if strings.Contains(stdout, "atm-meta") || !strings.Contains(stdout, "incident=INC-42,team=db") {
+			t.Fatalf("expected a meta column, got %q", stdout)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		records, err := csv.NewReader(strings.NewReader(query(t, "csv", "--show-meta"))).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header := records[0]; header[len(header)-1] != "meta" {
+			t.Fatalf("expected a meta column, got %q", header)
+		}
+		var metas []string
+		for _, r := range records[1:] {
+			if r[7] != "test" {
+				t.Fatalf("expected the human comment only, got %q", r[7])
+			}
+			metas = append(metas, r[8])
+		}
+		sort.Strings(metas)
+		if expected := []string{"", `{"incident":"INC-42","team":"db"}`}; !reflect.DeepEqual(metas, expected) {
+			t.Fatalf("expected the meta %q, got %q", expected, metas)
+		}
+	})
+
+	t.Run("template", func(t *testing.T) {
+		lines := strings.Fields(query(t, "template", `--template={{index .Meta "incident"}}`))
+		if expected := []string{"INC-42"}; !reflect.DeepEqual(lines, expected) {
+			t.Fatalf("expected %q, got %q", expected, lines)
+		}
+	})
+}
+
+func TestSilenceCountOutput(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	for i := 0; i < 3; i++ {
+		am.addSilence("tenant-a", newTestSilence(t, time.Hour, "alertname=foo"))
+	}
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\n")
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{
+			name:     "single tenant",
+			args:     []string{"silence", "count", "--tenant", "tenant-a"},
+			expected: "3\n",
+		},
+		{
+			name:     "single tenant json",
+			args:     []string{"--output=json", "silence", "count", "--tenant", "tenant-a"},
+			expected: `{"count":3}`,
+		},
+		{
+			name:     "tenant file",
+			args:     []string{"silence", "count", "--tenant.file", tenantFile},
+			expected: "tenant-a 3\ntenant-b 0\n",
+		},
+		{
+			name:     "tenant file json",
+			args:     []string{"--output=json", "silence", "count", "--tenant.file", tenantFile},
+			expected: `[{"tenant":"tenant-a","count":3},{"tenant":"tenant-b","count":0}]`,
+		},
+		{
+			name:     "query single tenant json",
+			args:     []string{"--output=json", "silence", "query", "--tenant", "tenant-a", "--all-authors", "--count"},
+			expected: `{"count":3}`,
+		},
+		{
+			name:     "query tenant file json",
+			args:     []string{"--output=json", "silence", "query", "--tenant.file", tenantFile, "--all-authors", "--count"},
+			expected: `[{"tenant":"tenant-a","count":3},{"tenant":"tenant-b","count":0}]`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			stdout, _, err := runCommand(t, tc.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if strings.HasPrefix(tc.expected, "[") || strings.HasPrefix(tc.expected, "{") {
+				var got, expected interface{}
+				if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+					t.Fatalf("expected JSON, got %q (%v)", stdout, err)
+				}
+				json.Unmarshal([]byte(tc.expected), &expected)
+				if !reflect.DeepEqual(got, expected) {
+					t.Fatalf("expected %s, got %s", tc.expected, stdout)
+				}
+				return
+			}
+			if stdout != tc.expected {
+				t.Fatalf("expected %q, got %q", tc.expected, stdout)
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-openapi/strfmt"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/matchers/compat"
+	"github.com/prometheus/alertmanager/pkg/labels"
+)
+
+type silenceUpdateCmd struct {
+	duration       string
+	maxDuration    string
+	end            string
+	comment        string
+	addMatchers    []string
+	removeMatchers []string
+	minSpecificity int
+	force          bool
+	ids            []string
+	md             model.Duration
+	tenantFlags
+}
+
+const silenceUpdateHelp = `Update alertmanager silences
+
+  The silences are fetched by their IDs, the changes given by the flags are
+  applied and the silences are posted back. The untouched fields are kept.
+
+  atm silence update <id> --duration 2h --comment 'still ongoing'
+
+	This statement will make the silence end 2h after its start, and replace
+	its comment.
+
+  atm silence update <id> --add-matcher node=bar --remove-matcher instance
+
+	This statement will add the node=bar matcher to the silence and remove
+	its matchers on the instance label.
+
+  A new end is checked against the max duration, and the matchers against
+  --min-specificity when they change, unless --force is given.
+
+  Alertmanager keeps the ID of the silence when only its end or comment
+  change, otherwise the silence is expired and a new one is created.
+`
+
+func configureSilenceUpdateCmd(cc *kingpin.CmdClause) {
+	var (
+		c         = &silenceUpdateCmd{}
+		updateCmd = cc.Command("update", silenceUpdateHelp)
+	)
+	c.tenantFlags.configure(updateCmd)
+	updateCmd.Flag("duration", "Duration of silence from its start").Short('d').StringVar(&c.duration)
+	updateCmd.Flag("max-duration", "Max Duration of silence, checked when --duration or --end is given").Default("12h").StringVar(&c.maxDuration)
+	updateCmd.Flag("end", "Set when the silence should end (overwrites duration). RFC3339 format 2006-01-02T15:04:05-07:00, or relative like +2h to the start or now+2h").StringVar(&c.end)
+	updateCmd.Flag("comment", "A comment to help describe the silence").Short('c').StringVar(&c.comment)
+	updateCmd.Flag("add-matcher", "Matcher to add to the silence").StringsVar(&c.addMatchers)
+	updateCmd.Flag("remove-matcher", "Label name of the matchers to remove from the silence").StringsVar(&c.removeMatchers)
+	updateCmd.Flag("min-specificity", "Minimum number of positive matchers not matching every alert when the matchers change, 0 to disable the check").Default("1").IntVar(&c.minSpecificity)
+	updateCmd.Flag("force", "Update the silence even when it lasts longer than --max-duration, or has less than --min-specificity specific matchers").BoolVar(&c.force)
+	updateCmd.Arg("silence-ids", "Ids of silences to update").StringsVar(&c.ids)
+	updateCmd.Action(execWithTimeout(c.update))
+}
+
+func (c *silenceUpdateCmd) update(ctx context.Context, _ *kingpin.ParseContext) error {
+	if len(c.ids) < 1 {
+		return errors.New("no silence IDs specified")
+	}
+	for _, s := range c.addMatchers {
+		if _, err := compat.Matcher(s, "cli"); err != nil {
+			return err
+		}
+	}
+	var err error
+	if c.md, err = parseMaxDuration(c.maxDuration); err != nil {
+		return err
+	}
+
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	var (
+		results []silenceResult
+		skipped []string
+	)
+	updated, failed := 0, 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	for i, t := range tenants {
+		if ctx.Err() != nil {
+			skipped = tenants[i:]
+			break
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		for _, id := range c.ids {
+			newID, err := c.updateSilence(ctx, amclient, t, id)
+			if err != nil {
+				failed++
+				results = append(results, silenceResult{Tenant: t, SilenceID: id, Status: "failed", Error: err.Error()})
+				logger.Error("Unable to update silence", "tenant", t, "id", id, "err", err)
+				recordFailure(t, err)
+				continue
+			}
+			updated++
+			results = append(results, silenceResult{Tenant: t, SilenceID: newID, Status: "updated"})
+			printResult(newID, "Silence updated%s: %s\n", forTenant(t), newID)
+		}
+	}
+
+	if err := printResults(results); err != nil {
+		return err
+	}
+
+	c.printSummary("updated", updated, failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(updated, fmt.Errorf("failed to update %d silence(s)", failed))
+	}
+	return nil
+}
+
+// updateSilence applies the changes to the silence and returns its ID after
+// the update.
+func (c *silenceUpdateCmd) updateSilence(ctx context.Context, amclient *client.AlertmanagerAPI, tenant, id string) (string, error) {
+	gs, err := getSilence(ctx, amclient, tenant, id)
+	if err != nil {
+		return "", err
+	}
+	sil := gs.Silence
+
+	startsAt := time.Time(*sil.StartsAt)
+	endsAt := time.Time(*sil.EndsAt)
+	if c.end != "" {
+		// A relative end is resolved against the start of the silence.
+		endsAt, err = parseTime(c.end, time.Now().UTC(), startsAt, time.Local)
+		if err != nil {
+			return "", err
+		}
+	} else if c.duration != "" {
+		d, err := model.ParseDuration(c.duration)
+		if err != nil {
+			return "", err
+		}
+		if d == 0 {
+			return "", fmt.Errorf("silence duration must be greater than 0")
+		}
+		endsAt = startsAt.Add(time.Duration(d))
+	}
+	if !endsAt.After(startsAt) {
+		return "", errors.New("silence cannot start after it ends")
+	}
+	// Only a new end is checked, the silences added before the max duration
+	// was lowered can still be updated.
+	if (c.end != "" || c.duration != "") && !c.force {
+		duration := model.Duration(endsAt.Sub(startsAt))
+		if limit, source := c.tenantMaxDuration(tenant, c.md); duration > limit {
+			return "", fmt.Errorf("silence duration '%s' couldn't be greater than '%s' set by %s, use --force to update it anyway", duration, limit, source)
+		}
+	}
+	end := strfmt.DateTime(endsAt)
+	sil.EndsAt = &end
+
+	if c.comment != "" {
+		// The metadata of the previous comment are kept.
+		_, meta := parseCommentMeta(*sil.Comment)
+		comment := withCommentMeta(c.comment, meta)
+		sil.Comment = &comment
+	}
+
+	if len(c.removeMatchers) > 0 {
+		remove := make(map[string]bool, len(c.removeMatchers))
+		for _, name := range c.removeMatchers {
+			remove[name] = true
+		}
+		matchers := make(models.Matchers, 0, len(sil.Matchers))
+		for _, m := range sil.Matchers {
+			if !remove[*m.Name] {
+				matchers = append(matchers, m)
+			}
+		}
+		sil.Matchers = matchers
+	}
+	for _, s := range c.addMatchers {
+		m, err := compat.Matcher(s, "cli")
+		if err != nil {
+			return "", err
+		}
+		sil.Matchers = append(sil.Matchers, TypeMatcher(*m))
+	}
+	if len(sil.Matchers) < 1 {
+		return "", errors.New("no matchers left")
+	}
+	if len(c.addMatchers) > 0 || len(c.removeMatchers) > 0 {
+		matchers := make([]labels.Matcher, 0, len(sil.Matchers))
+		for _, m := range sil.Matchers {
+			// The regexes are compiled, for checkSpecificity to match them.
+			lm := labelsMatcher(*m)
+			nm, err := labels.NewMatcher(lm.Type, lm.Name, lm.Value)
+			if err != nil {
+				return "", fmt.Errorf("invalid matcher '%s': %v", lm.String(), err)
+			}
+			matchers = append(matchers, *nm)
+		}
+		if err := validateMatchers(matchers); err != nil {
+			return "", err
+		}
+		if !c.force {
+			if err := checkSpecificity(matchers, c.minSpecificity); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return postSilence(ctx, amclient, tenant, id, sil)
+}
+
+// getSilence fetches the silence, with a clear error when it doesn't exist.
+func getSilence(ctx context.Context, amclient *client.AlertmanagerAPI, tenant, id string) (*models.GettableSilence, error) {
+	params := silence.NewGetSilenceParams().WithContext(ctx).WithTimeout(timeout)
+	params.SilenceID = strfmt.UUID(id)
+	var getOk *silence.GetSilenceOK
+	err := withRetry(ctx, fmt.Sprintf("get silence '%s'%s", id, forTenant(tenant)), func() (err error) {
+		getOk, err = amclient.Silence.GetSilence(params)
+		return err
+	})
+	if err != nil {
+		var notFound *silence.GetSilenceNotFound
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("silence not found")
+		}
+		return nil, err
+	}
+	return getOk.Payload, nil
+}
+
+// postSilence posts the silence back as an update of the given ID, and
+// returns the ID of the silence after the update.
+func postSilence(ctx context.Context, amclient *client.AlertmanagerAPI, tenant, id string, sil models.Silence) (string, error) {
+	ps := &models.PostableSilence{
+		ID:      id,
+		Silence: sil,
+	}
+	params := silence.NewPostSilencesParams().WithContext(ctx).WithTimeout(timeout).WithSilence(ps)
+	var postOk *silence.PostSilencesOK
+	err := withRetry(ctx, fmt.Sprintf("update silence '%s'%s", id, forTenant(tenant)), func() (err error) {
+		postOk, err = amclient.Silence.PostSilences(params)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return postOk.Payload.SilenceID, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+)
+
+func TestUpdateSilenceValidation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		existing time.Duration
+		matchers []string
+		cmd      silenceUpdateCmd
+		configs  map[string]tenantConfig
+		err      string
+	}{
+		{
+			name:     "comment of a silence longer than the max duration",
+			existing: 48 * time.Hour,
+			cmd:      silenceUpdateCmd{comment: "still ongoing"},
+		},
+		{
+			name:     "duration longer than the max duration",
+			existing: time.Hour,
+			cmd:      silenceUpdateCmd{duration: "24h"},
+			err:      "silence duration '1d' couldn't be greater than '12h' set by --max-duration",
+		},
+		{
+			name:     "end beyond the max duration",
+			existing: time.Hour,
+			cmd:      silenceUpdateCmd{end: "+13h"},
+			err:      "couldn't be greater than '12h' set by --max-duration",
+		},
+		{
+			name:     "forced duration longer than the max duration",
+			existing: time.Hour,
+			cmd:      silenceUpdateCmd{duration: "24h", force: true},
+		},
+		{
+			name:     "duration longer than the max duration of the tenant",
+			existing: time.Hour,
+			cmd:      silenceUpdateCmd{duration: "4h"},
+			configs:  map[string]tenantConfig{"tenant-a": {Tenant: "tenant-a", MaxDuration: "2h", maxDuration: model.Duration(2 * time.Hour)}},
+			err:      "set by the max_duration of 'tenant-a' tenant",
+		},
+		{
+			name:     "removed matchers leaving a too broad silence",
+			existing: time.Hour,
+			matchers: []string{"alertname=~.*"},
+			cmd:      silenceUpdateCmd{removeMatchers: []string{"alertname"}, addMatchers: []string{"instance!=foo"}},
+			err:      "silence is too broad",
+		},
+		{
+			name:     "added matchers to a broad silence",
+			existing: time.Hour,
+			matchers: []string{"alertname=~.*"},
+			cmd:      silenceUpdateCmd{addMatchers: []string{"instance!=foo"}},
+			err:      "silence is too broad",
+		},
+		{
+			name:     "forced too broad silence",
+			existing: time.Hour,
+			matchers: []string{"alertname=~.*"},
+			cmd:      silenceUpdateCmd{addMatchers: []string{"instance!=foo"}, force: true},
+		},
+		{
+			name:     "added matcher with an invalid value",
+			existing: time.Hour,
+			cmd:      silenceUpdateCmd{addMatchers: []string{"instance=\"\xff\""}},
+			err:      "not valid UTF-8",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			am := newFakeAlertmanager(t)
+			matchers := tc.matchers
+			if matchers == nil {
+				matchers = []string{"alertname=foo"}
+			}
+			id := am.addSilence("tenant-a", newTestSilence(t, tc.existing, matchers...))
+
+			c := tc.cmd
+			c.tenantFlags = testTenantFlags()
+			c.configs = tc.configs
+			c.minSpecificity = 1
+			c.md = model.Duration(12 * time.Hour)
+			_, err := c.updateSilence(context.Background(), testClient(t, &c.tenantFlags, "tenant-a"), "tenant-a", id)
+			switch {
+			case tc.err == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
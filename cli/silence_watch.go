@@ -0,0 +1,214 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-openapi/strfmt"
+	"github.com/prometheus/common/model"
+
+	"github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+	"github.com/prometheus/alertmanager/api/v2/models"
+)
+
+type silenceWatchCmd struct {
+	by           string
+	renewBefore  string
+	maxDuration  string
+	expireOnExit bool
+	ids          []string
+	tenantFlags
+}
+
+const silenceWatchHelp = `Keep alertmanager silences from expiring
+
+  The silences are renewed while atm is running, for maintenance windows
+  longer than --max-duration:
+
+  atm silence watch <id> --renew-before 5m --by 1h
+
+	This statement makes the silence end 1h from now each time it's about to
+	end in less than 5m, until atm is interrupted.
+
+  atm silence watch <id> --by 1h --expire-on-exit
+
+	On SIGINT or SIGTERM, or once --deadline is reached, the silences are
+	expired with --expire-on-exit, so that the maintenance window ends with
+	atm. Otherwise they end at their current end.
+`
+
+func configureSilenceWatchCmd(cc *kingpin.CmdClause) {
+	var (
+		c        = &silenceWatchCmd{}
+		watchCmd = cc.Command("watch", silenceWatchHelp)
+	)
+	c.tenantFlags.configure(watchCmd)
+	watchCmd.Flag("by", "Duration from now the silence ends at once renewed").Default("1h").StringVar(&c.by)
+	watchCmd.Flag("renew-before", "Renew the silence when it ends in less than this duration").Default("5m").StringVar(&c.renewBefore)
+	watchCmd.Flag("max-duration", "Max duration of each renewal").Default("12h").StringVar(&c.maxDuration)
+	watchCmd.Flag("expire-on-exit", "Expire the silences when atm is interrupted").BoolVar(&c.expireOnExit)
+	watchCmd.Arg("silence-ids", "Ids of silences to renew").Required().StringsVar(&c.ids)
+	watchCmd.Action(execWithTimeout(c.watch))
+}
+
+// watchedSilence is a silence renewed by watch. Its ID changes when
+// Alertmanager replaces it on renewal.
+type watchedSilence struct {
+	tenant   string
+	id       string
+	endsAt   time.Time
+	amclient *client.AlertmanagerAPI
+}
+
+func (c *silenceWatchCmd) watch(ctx context.Context, _ *kingpin.ParseContext) error {
+	by, err := model.ParseDuration(c.by)
+	if err != nil {
+		return err
+	}
+	renewBefore, err := model.ParseDuration(c.renewBefore)
+	if err != nil {
+		return err
+	}
+	md, err := parseMaxDuration(c.maxDuration)
+	if err != nil {
+		return err
+	}
+	if by <= renewBefore {
+		return errors.New("--by must be greater than --renew-before")
+	}
+
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var watched []*watchedSilence
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	for _, t := range tenants {
+		// The max_duration of the tenant config takes precedence, like for
+		// silence update.
+		if limit, source := c.tenantMaxDuration(t, md); by > limit {
+			return fmt.Errorf("renewal duration '%s' couldn't be greater than '%s' set by %s", by, limit, source)
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+		for _, id := range c.ids {
+			gs, err := getSilence(ctx, amclient, t, id)
+			if err != nil {
+				return fmt.Errorf("Unable to get silence '%s'%s: %v", id, forTenant(t), err)
+			}
+			if *gs.Status.State == models.SilenceStatusStateExpired {
+				return fmt.Errorf("silence '%s'%s is expired", id, forTenant(t))
+			}
+			watched = append(watched, &watchedSilence{tenant: t, id: id, endsAt: time.Time(*gs.EndsAt), amclient: amclient})
+		}
+	}
+
+	for {
+		next := time.Duration(by)
+		for _, w := range watched {
+			if time.Until(w.endsAt) <= time.Duration(renewBefore) {
+				if err := c.renew(ctx, w, time.Duration(by)); err != nil {
+					if ctx.Err() != nil {
+						break
+					}
+					logger.Error("Unable to renew silence", "tenant", w.tenant, "id", w.id, "err", err)
+					// Try again shortly, the silence isn't lost yet.
+					next = min(next, retryBackoff)
+					continue
+				}
+			}
+			next = min(next, time.Until(w.endsAt)-time.Duration(renewBefore))
+		}
+
+		select {
+		case <-ctx.Done():
+			return c.exit(watched)
+		case <-time.After(max(next, time.Second)):
+		}
+	}
+}
+
+// renew makes the silence end after the given duration from now.
+func (c *silenceWatchCmd) renew(ctx context.Context, w *watchedSilence, by time.Duration) error {
+	gs, err := getSilence(ctx, w.amclient, w.tenant, w.id)
+	if err != nil {
+		return err
+	}
+	if *gs.Status.State == models.SilenceStatusStateExpired {
+		return errors.New("silence is expired")
+	}
+	sil := gs.Silence
+	endsAt := time.Now().UTC().Add(by)
+	end := strfmt.DateTime(endsAt)
+	sil.EndsAt = &end
+
+	newID, err := postSilence(ctx, w.amclient, w.tenant, w.id, sil)
+	if err != nil {
+		return err
+	}
+	logger.Info("Silence renewed", "tenant", w.tenant, "id", newID, "previous_id", w.id, "ends_at", endsAt.Format(time.RFC3339))
+	w.id, w.endsAt = newID, endsAt
+	return nil
+}
+
+// exit expires the silences with --expire-on-exit once the watch is over.
+func (c *silenceWatchCmd) exit(watched []*watchedSilence) error {
+	if !c.expireOnExit {
+		for _, w := range watched {
+			logger.Info("Silence no longer renewed", "tenant", w.tenant, "id", w.id, "ends_at", w.endsAt.Format(time.RFC3339))
+		}
+		return nil
+	}
+
+	// The watch context is done, the silences are expired with a new one.
+	ctx := context.Background()
+	failed := 0
+	for _, w := range watched {
+		params := silence.NewDeleteSilenceParams().WithContext(ctx).WithTimeout(timeout)
+		params.SilenceID = strfmt.UUID(w.id)
+		err := withRetry(ctx, fmt.Sprintf("expire silence '%s'%s", w.id, forTenant(w.tenant)), func() error {
+			_, err := w.amclient.Silence.DeleteSilence(params)
+			return err
+		})
+		if err != nil {
+			failed++
+			logger.Error("Unable to expire silence", "tenant", w.tenant, "id", w.id, "err", err)
+			recordFailure(w.tenant, err)
+			continue
+		}
+		printResult(w.id, "Silence expired%s: %s\n", forTenant(w.tenant), w.id)
+	}
+	if failed > 0 {
+		return failure(len(watched)-failed, fmt.Errorf("failed to expire %d silence(s)", failed))
+	}
+	return nil
+}
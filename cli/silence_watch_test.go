@@ -0,0 +1,56 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSilenceWatchTenantMaxDuration(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	id := am.addSilence("tenant-a", newTestSilence(t, time.Hour, "alertname=foo"))
+	for _, tc := range []struct {
+		name        string
+		maxDuration string
+		by          string
+		err         string
+	}{
+		{
+			name:        "renewal longer than the max duration of the tenant",
+			maxDuration: "30m",
+			by:          "1h",
+			err:         "renewal duration '1h' couldn't be greater than '30m' set by the max_duration of 'tenant-a' tenant",
+		},
+		{
+			name:        "renewal longer than --max-duration",
+			maxDuration: "1d",
+			by:          "13h",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tenantFile := writeTenantFile(t, "tenants.yml", "- tenant: tenant-a\n  max_duration: "+tc.maxDuration+"\n")
+			_, _, err := runCommand(t, "--deadline=100ms", "silence", "watch", id, "--by", tc.by, "--tenant.file", tenantFile)
+			if tc.err == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.err {
+				t.Fatalf("expected error %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+
+	"github.com/prometheus/alertmanager/api/v2/client/general"
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/cli/format"
+)
+
+type statusCmd struct {
+	tenantFlags
+}
+
+const statusHelp = `Show the Alertmanager status
+
+  The uptime, version, cluster status and hash of the configuration of the
+  Alertmanager are printed, to check the connectivity before running bulk
+  operations. Nothing is changed.
+
+  atm status --tenant tenant-a
+
+	Some multi-tenant proxies scope the status by tenant, which is selected
+	as for the silence commands.
+`
+
+// configureStatusCmd represents the status command.
+func configureStatusCmd(app *kingpin.Application) {
+	var (
+		c   = &statusCmd{}
+		cmd = app.Command("status", statusHelp).PreAction(requireAlertManagerURL)
+	)
+	c.tenantFlags.configure(cmd)
+	cmd.Action(execWithTimeout(c.status))
+}
+
+// tenantStatusJSON is the JSON representation of the status of a tenant.
+type tenantStatusJSON struct {
+	Tenant string                     `json:"tenant"`
+	Status *models.AlertmanagerStatus `json:"status"`
+}
+
+func (c *statusCmd) status(ctx context.Context, _ *kingpin.ParseContext) error {
+	tenants, err := c.tenants()
+	if err != nil {
+		return err
+	}
+
+	var (
+		results []tenantStatusJSON
+		skipped []string
+	)
+	failed := 0
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return err
+	}
+	for i, t := range tenants {
+		if ctx.Err() != nil {
+			skipped = tenants[i:]
+			break
+		}
+		amclient, err := c.newClient(httpConfig, t)
+		if err != nil {
+			return err
+		}
+
+		params := general.NewGetStatusParams().WithContext(ctx).WithTimeout(timeout)
+		var getOk *general.GetStatusOK
+		err = withRetry(ctx, "get status"+forTenant(t), func() (err error) {
+			getOk, err = amclient.General.GetStatus(params)
+			return err
+		})
+		if err != nil {
+			if !c.multiTenant() {
+				return fmt.Errorf("Unable to get status%s: %v", forTenant(t), err)
+			}
+			failed++
+			logger.Error("Unable to get status", "tenant", t, "err", err)
+			recordFailure(t, err)
+			continue
+		}
+		results = append(results, tenantStatusJSON{Tenant: t, Status: getOk.Payload})
+	}
+	if err := c.print(results); err != nil {
+		return err
+	}
+
+	c.printSummary("checked", len(results), failed, len(tenants))
+	if len(skipped) > 0 {
+		return skippedError(skipped)
+	}
+	if failed > 0 {
+		return failure(len(results), fmt.Errorf("failed to get status for %d tenant(s)", failed))
+	}
+	return nil
+}
+
+// print writes the status as JSON or YAML with --output=json and
+// --output=yaml, and as a table for the other outputs.
+func (c *statusCmd) print(results []tenantStatusJSON) error {
+	if structuredOutput() {
+		if c.multiTenant() {
+			return printStructured(results)
+		}
+		formatter, found := format.Formatters[output]
+		if !found {
+			return errors.New("unknown output formatter")
+		}
+		for _, r := range results {
+			if err := formatter.FormatConfig(r.Status); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i, r := range results {
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		if err := printStatus(r.Tenant, r.Status); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printStatus(tenant string, s *models.AlertmanagerStatus) error {
+	w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	if tenant != "" {
+		fmt.Fprintf(w, "Tenant:\t%s\n", tenant)
+	}
+	if s.Uptime != nil {
+		since := time.Since(time.Time(*s.Uptime)).Round(time.Second)
+		fmt.Fprintf(w, "Uptime:\t%s (since %s)\n", since, format.FormatDate(*s.Uptime))
+	}
+	if v := s.VersionInfo; v != nil && v.Version != nil {
+		fmt.Fprintf(w, "Version:\t%s\n", *v.Version)
+	}
+	if cs := s.Cluster; cs != nil && cs.Status != nil {
+		fmt.Fprintf(w, "Cluster Status:\t%s\n", *cs.Status)
+		fmt.Fprintf(w, "Cluster Peers:\t%d\n", len(cs.Peers))
+	}
+	if s.Config != nil && s.Config.Original != nil {
+		hash := sha256.Sum256([]byte(*s.Config.Original))
+		fmt.Fprintf(w, "Config Hash:\t%s\n", hex.EncodeToString(hash[:]))
+	}
+	return w.Flush()
+}
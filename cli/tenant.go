@@ -0,0 +1,813 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-openapi/runtime"
+	"github.com/go-openapi/strfmt"
+	promconfig "github.com/prometheus/common/config"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+
+	"github.com/prometheus/alertmanager/api/v2/client"
+)
+
+// tenantFlags holds the flags selecting the tenant(s) a silence command applies to.
+type tenantFlags struct {
+	tenant           string
+	tenantFile       string
+	tenantFileColumn string
+	tenantCSVHeader  string
+	tenantRegex      string
+	tenantExclude    string
+	tenantLimit      int
+	tenantOffset     int
+	tenantFromStatus bool
+	tenantStatusPath string
+	tenantHTTPHeader string
+	tenantDelimiter  string
+	tenantPrefix     string
+	tenantSuffix     string
+	summary          bool
+
+	// configs holds the tenant configs read from a YAML tenant file.
+	configs map[string]tenantConfig
+	// selected caches the tenants returned by tenants for the run.
+	selected []string
+}
+
+func (t *tenantFlags) configure(cmd *kingpin.CmdClause) {
+	cmd.Flag("tenant", "tenant, or comma separated tenants sent together in the tenant header").Short('t').HintAction(t.tenantHints).StringVar(&t.tenant)
+	stdinFileVar(cmd.Flag("tenant.file", "tenant file location, '-' reads the tenants from stdin").PlaceHolder("<filename>"), &t.tenantFile)
+	cmd.Flag("tenant.file.column", "Column of the tenants in a CSV tenant file, by header name or position from 1").PlaceHolder("<column>").StringVar(&t.tenantFileColumn)
+	cmd.Flag("tenant.csv-header", "Whether the first line of a CSV tenant file is a header (auto, always, never), auto detecting a header with a 'tenant' column or the --tenant.file.column one").Default("auto").EnumVar(&t.tenantCSVHeader, "auto", "always", "never")
+	cmd.Flag("tenant.regex", "Only the tenants of the tenant file fully matching this regex").PlaceHolder("<regex>").StringVar(&t.tenantRegex)
+	cmd.Flag("tenant.exclude-regex", "Skip the tenants of the tenant file fully matching this regex").PlaceHolder("<regex>").StringVar(&t.tenantExclude)
+	cmd.Flag("tenant.from-status", "List the tenants from the status endpoint of a multi-tenant Alertmanager instead of a tenant file").BoolVar(&t.tenantFromStatus)
+	cmd.Flag("tenant.status-path", "Path of the endpoint listing the tenants, relative to --alertmanager.url unless starting with '/'").PlaceHolder("<path>").StringVar(&t.tenantStatusPath)
+	cmd.Flag("tenant.offset", "Skip the first tenants of the tenant file, after filtering, for batched runs").PlaceHolder("<M>").IntVar(&t.tenantOffset)
+	cmd.Flag("tenant.limit", "Only the given number of tenants of the tenant file, after filtering and the offset, for batched runs").PlaceHolder("<N>").IntVar(&t.tenantLimit)
+	t.configureHeader(cmd)
+}
+
+// configureHeader only configures the flags building the tenant header, for
+// the commands getting the tenants from elsewhere.
+func (t *tenantFlags) configureHeader(cmd *kingpin.CmdClause) {
+	cmd.Flag("tenant.http-header", "tenant HTTP Header").Default("X-Scope-OrgID").StringVar(&t.tenantHTTPHeader)
+	cmd.Flag("tenant.delimiter", "Delimiter joining the tenants of --tenant in the tenant header").Default("|").StringVar(&t.tenantDelimiter)
+	cmd.Flag("tenant.prefix", "Prefix added to each tenant in the tenant header, like 'org-'").StringVar(&t.tenantPrefix)
+	cmd.Flag("tenant.suffix", "Suffix added to each tenant in the tenant header").StringVar(&t.tenantSuffix)
+	cmd.Flag("summary", "Print a summary to stderr after a multi-tenant run").Default("true").BoolVar(&t.summary)
+}
+
+// tenantHints completes --tenant with the tenants of the tenant file, given on
+// the command line or in the config file, or with the tenants of the config
+// file.
+func (t *tenantFlags) tenantHints() []string {
+	if t.tenantFile == "" {
+		return configTenants
+	}
+	if t.tenantFile == "-" {
+		return nil
+	}
+	tenants, _, err := readTenantFromFile(t.tenantFile, t.tenantFileColumn, t.tenantCSVHeader)
+	if err != nil {
+		return nil
+	}
+	return tenants
+}
+
+// multiTenant reports whether the tenants are read from a tenant file or the
+// config file, rather than given by --tenant.
+func (t *tenantFlags) multiTenant() bool {
+	return t.tenantFile != "" || t.tenantFromStatus || (t.tenant == "" && len(configTenants) > 0)
+}
+
+// tenants returns the tenants selected by the flags, or the tenants of the
+// config file if none is. When no tenant is
+// selected it returns a single empty tenant, meaning that no tenant header
+// must be sent, so that callers can always iterate over the result.
+//
+// The HTTP config files of the tenants in --http.config.dir are checked
+// before any tenant is processed. The tenants are cached, so that the tenant
+// file or the status endpoint are only read once per run.
+func (t *tenantFlags) tenants() ([]string, error) {
+	if t.selected != nil {
+		return t.selected, nil
+	}
+	tenants, err := t.selectedTenants()
+	if err != nil {
+		return nil, err
+	}
+	for _, tenant := range tenants {
+		if file := t.httpConfigFile(tenant); file != "" {
+			if _, _, err := promconfig.LoadHTTPConfigFile(file); err != nil {
+				return nil, fmt.Errorf("invalid HTTP config file '%s'%s: %v", file, forTenant(tenant), err)
+			}
+		}
+	}
+	t.selected = tenants
+	return tenants, nil
+}
+
+func (t *tenantFlags) selectedTenants() ([]string, error) {
+	if t.tenant != "" && t.tenantFile != "" {
+		return nil, errors.New("tenant and tenant.file are mutually exclusive")
+	}
+	if t.tenantDelimiter == "" {
+		// strings.Split would split the tenants into their characters.
+		return nil, errors.New("tenant.delimiter must not be empty")
+	}
+	include, err := compileTenantRegex("tenant.regex", t.tenantRegex)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileTenantRegex("tenant.exclude-regex", t.tenantExclude)
+	if err != nil {
+		return nil, err
+	}
+	if t.tenantFromStatus {
+		if t.tenant != "" || t.tenantFile != "" {
+			return nil, errors.New("tenant.from-status is mutually exclusive with tenant and tenant.file")
+		}
+		tenants, err := readTenantsFromStatus(t.tenantStatusPath)
+		if err != nil {
+			return nil, err
+		}
+		if len(tenants) == 0 {
+			return nil, errors.New("no tenant listed by the status endpoint")
+		}
+		return t.selectTenants(tenants, include, exclude)
+	}
+	if t.tenantFile != "" {
+		tenants, configs, err := readTenantFromFile(t.tenantFile, t.tenantFileColumn, t.tenantCSVHeader)
+		if err != nil {
+			return nil, err
+		}
+		t.configs = configs
+		return t.selectTenants(tenants, include, exclude)
+	}
+	if t.tenant == "" && len(configTenants) > 0 {
+		return t.selectTenants(configTenants, include, exclude)
+	}
+	if include != nil || exclude != nil {
+		return nil, errors.New("tenant.regex and tenant.exclude-regex only filter the tenants of a tenant file or of the config file")
+	}
+	if t.tenantLimit != 0 || t.tenantOffset != 0 {
+		return nil, errors.New("tenant.limit and tenant.offset only apply to the tenants of a tenant file or of the config file")
+	}
+	if strings.Contains(t.tenant, ",") {
+		// Mimir and Cortex accept several tenants in a single header value.
+		var ids []string
+		for _, id := range strings.Split(t.tenant, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		return []string{strings.Join(ids, t.tenantDelimiter)}, nil
+	}
+	return []string{t.tenant}, nil
+}
+
+// selectTenants returns the tenants filtered by the regexes, within the
+// window of --tenant.offset and --tenant.limit.
+func (t *tenantFlags) selectTenants(tenants []string, include, exclude *regexp.Regexp) ([]string, error) {
+	tenants, err := filterTenants(tenants, include, exclude)
+	if err != nil {
+		return nil, err
+	}
+	return t.windowTenants(tenants)
+}
+
+// windowTenants returns the tenants from --tenant.offset, at most
+// --tenant.limit of them, so that a run touches only a batch of the tenants.
+func (t *tenantFlags) windowTenants(tenants []string) ([]string, error) {
+	if t.tenantLimit == 0 && t.tenantOffset == 0 {
+		return tenants, nil
+	}
+	if t.tenantOffset < 0 {
+		return nil, fmt.Errorf("invalid --tenant.offset %d, it must not be negative", t.tenantOffset)
+	}
+	if t.tenantLimit < 0 {
+		return nil, fmt.Errorf("invalid --tenant.limit %d, it must not be negative", t.tenantLimit)
+	}
+	if t.tenantOffset >= len(tenants) {
+		return nil, fmt.Errorf("--tenant.offset %d is beyond the %d tenants", t.tenantOffset, len(tenants))
+	}
+	end := len(tenants)
+	if t.tenantLimit > 0 {
+		end = min(end, t.tenantOffset+t.tenantLimit)
+	}
+	logger.Info("Tenants batch", "offset", t.tenantOffset, "count", end-t.tenantOffset, "total", len(tenants))
+	return tenants[t.tenantOffset:end], nil
+}
+
+// compileTenantRegex compiles the regex of the flag, anchored like the
+// regex matchers. It returns nil if the flag isn't set.
+func compileTenantRegex(flag, pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid --%s: %v", flag, err)
+	}
+	return re, nil
+}
+
+// filterTenants returns the tenants matching include and not matching
+// exclude, when set, and logs how many matched.
+func filterTenants(tenants []string, include, exclude *regexp.Regexp) ([]string, error) {
+	if include == nil && exclude == nil {
+		return tenants, nil
+	}
+	var matched []string
+	for _, t := range tenants {
+		if (include == nil || include.MatchString(t)) && (exclude == nil || !exclude.MatchString(t)) {
+			matched = append(matched, t)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf("none of the %d tenants matches the tenant regexes", len(tenants))
+	}
+	logger.Info("Tenants filtered", "matched", len(matched), "total", len(tenants))
+	return matched, nil
+}
+
+// newClient returns an alertmanager client sending the tenant HTTP header,
+// or no tenant header at all when tenant is empty. The URL and HTTP config
+// of the tenant config, if any, take precedence over the global ones.
+//
+// The clients of the tenants sharing the same URLs and HTTP config share the
+// same transport, so that the connections are reused from one tenant to the
+// next.
+func (t *tenantFlags) newClient(httpConfig *promconfig.HTTPClientConfig, tenant string) (*client.AlertmanagerAPI, error) {
+	key := t.transportKey(httpConfig, tenant)
+	transportsMtx.Lock()
+	defer transportsMtx.Unlock()
+	rt, ok := transports[key]
+	if !ok {
+		amURLs, cfg, err := t.clientConfig(httpConfig, tenant)
+		if err != nil {
+			return nil, err
+		}
+		if rt, err = newTransport(amURLs, *cfg); err != nil {
+			return nil, err
+		}
+		transports[key] = rt
+	}
+	return t.tenantClient(rt, tenant), nil
+}
+
+var (
+	transportsMtx sync.Mutex
+	// transports holds the transports built by newClient, by transportKey.
+	transports = map[transportKey]runtime.ClientTransport{}
+)
+
+// transportKey identifies the global HTTP config, and the URLs and the HTTP
+// config file of the tenant client. The global HTTP config is built once per
+// run, a new one never sharing the transports of the previous one.
+type transportKey struct {
+	httpConfig *promconfig.HTTPClientConfig
+	file       string
+	urls       string
+}
+
+// transportKey returns the key of the transport of the tenant client.
+func (t *tenantFlags) transportKey(httpConfig *promconfig.HTTPClientConfig, tenant string) transportKey {
+	amURLs := []*url.URL(alertmanagerURLs)
+	if tc, ok := t.configs[tenant]; ok && tc.url != nil {
+		amURLs = []*url.URL{tc.url}
+	}
+	key := transportKey{httpConfig: httpConfig, file: t.httpConfigFile(tenant)}
+	for _, u := range amURLs {
+		key.urls += " " + u.String()
+	}
+	return key
+}
+
+// clientConfig returns the Alertmanager URLs and the HTTP config of the
+// tenant client.
+func (t *tenantFlags) clientConfig(httpConfig *promconfig.HTTPClientConfig, tenant string) ([]*url.URL, *promconfig.HTTPClientConfig, error) {
+	amURLs := []*url.URL(alertmanagerURLs)
+	if tc, ok := t.configs[tenant]; ok && tc.url != nil {
+		amURLs = []*url.URL{tc.url}
+	}
+	if file := t.httpConfigFile(tenant); file != "" {
+		var err error
+		httpConfig, _, err = promconfig.LoadHTTPConfigFile(file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to load HTTP config file%s: %v", forTenant(tenant), err)
+		}
+		if err := addHTTPHeaders(httpConfig); err != nil {
+			return nil, nil, err
+		}
+	}
+	return amURLs, httpConfig, nil
+}
+
+// httpConfigFile returns the HTTP config file of the tenant, from its tenant
+// config or otherwise --http.config.dir, if any. The tenants without one use
+// the global HTTP config.
+func (t *tenantFlags) httpConfigFile(tenant string) string {
+	if tc, ok := t.configs[tenant]; ok && tc.HTTPConfigFile != "" {
+		return tc.HTTPConfigFile
+	}
+	// The tenants which aren't a valid file name never have a file.
+	if httpConfigDir == "" || tenant == "" || tenant != filepath.Base(tenant) || tenant == ".." {
+		return ""
+	}
+	file := filepath.Join(httpConfigDir, tenant+".yml")
+	if _, err := os.Stat(file); err != nil {
+		return ""
+	}
+	return file
+}
+
+// tenantClient returns an alertmanager client submitting its requests to rt
+// with the tenant HTTP header, if tenant isn't empty.
+func (t *tenantFlags) tenantClient(rt runtime.ClientTransport, tenant string) *client.AlertmanagerAPI {
+	if tenant != "" {
+		header := t.tenantHTTPHeader
+		if tc, ok := t.configs[tenant]; ok && tc.Header != "" {
+			header = tc.Header
+		}
+		redactHeader(header)
+		rt = &tenantTransport{ClientTransport: rt, header: header, value: t.headerValue(tenant)}
+	}
+	return client.New(rt, strfmt.Default)
+}
+
+// tenantTransport sets the tenant header on each request it submits. The
+// header is written with the request parameters, so the requests of the
+// tenants processed in parallel never share it.
+type tenantTransport struct {
+	runtime.ClientTransport
+	header, value string
+}
+
+func (t *tenantTransport) Submit(op *runtime.ClientOperation) (interface{}, error) {
+	params := op.Params
+	op.Params = runtime.ClientRequestWriterFunc(func(req runtime.ClientRequest, reg strfmt.Registry) error {
+		if params != nil {
+			if err := params.WriteToRequest(req, reg); err != nil {
+				return err
+			}
+		}
+		return req.SetHeaderParam(t.header, t.value)
+	})
+	return t.ClientTransport.Submit(op)
+}
+
+// headerValue returns the tenant header value of the tenant, with the prefix
+// and suffix added to each of the tenants joined by the delimiter.
+func (t *tenantFlags) headerValue(tenant string) string {
+	if t.tenantPrefix == "" && t.tenantSuffix == "" {
+		return tenant
+	}
+	ids := strings.Split(tenant, t.tenantDelimiter)
+	for i, id := range ids {
+		ids[i] = t.tenantPrefix + id + t.tenantSuffix
+	}
+	return strings.Join(ids, t.tenantDelimiter)
+}
+
+// tenantMaxDuration returns the max duration of the silences of the tenant, from
+// its tenant config or otherwise the given --max-duration, and where it comes
+// from.
+func (t *tenantFlags) tenantMaxDuration(tenant string, md model.Duration) (model.Duration, string) {
+	if tc, ok := t.configs[tenant]; ok && tc.MaxDuration != "" {
+		return tc.maxDuration, fmt.Sprintf("the max_duration of '%s' tenant", tenant)
+	}
+	return md, "--max-duration"
+}
+
+// tenantComment returns the comment of the tenant config of the tenant, if
+// any.
+func (t *tenantFlags) tenantComment(tenant string) (string, bool) {
+	tc, ok := t.configs[tenant]
+	return tc.Comment, ok && tc.Comment != ""
+}
+
+// tenantMaxDurations reports whether some tenants have their own max
+// duration.
+func (t *tenantFlags) tenantMaxDurations() bool {
+	for _, tc := range t.configs {
+		if tc.MaxDuration != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// printSummary writes the outcome of a multi-tenant run to stderr, unless
+// disabled with --no-summary or --quiet.
+func (t *tenantFlags) printSummary(action string, done, failed, tenants int) {
+	if !t.summary || quiet || !t.multiTenant() {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s %d, failed %d across %d tenants\n", action, done, failed, tenants)
+}
+
+// forTenant returns the message suffix naming the tenant, if any.
+func forTenant(tenant string) string {
+	if tenant == "" {
+		return ""
+	}
+	return fmt.Sprintf(" for '%s' tenant", tenant)
+}
+
+// skippedError reports the tenants which were never processed because the
+// deadline was reached, on stderr and in --skipped-tenants-file, so that the
+// command can be run again for them only.
+func skippedError(skipped []string) error {
+	fmt.Fprintf(os.Stderr, "The deadline was reached, %d tenant(s) skipped:\n", len(skipped))
+	for _, t := range skipped {
+		fmt.Fprintf(os.Stderr, "  %s\n", t)
+	}
+	if skippedTenantsFile != "" {
+		content := strings.Join(skipped, "\n") + "\n"
+		if err := os.WriteFile(skippedTenantsFile, []byte(content), 0o644); err != nil {
+			logger.Error("Unable to write the skipped tenants", "file", skippedTenantsFile, "err", err)
+		}
+	}
+	return &codeError{code: exitDeadline, err: fmt.Errorf("deadline reached, %d tenant(s) skipped", len(skipped))}
+}
+
+// tenantResult holds the outcome of an operation run for a tenant.
+type tenantResult struct {
+	tenant string
+	id     string
+	err    error
+}
+
+// forEachTenant runs fn for every tenant using at most concurrency goroutines,
+// with a progress bar on terminals. A failure for a tenant doesn't stop the
+// others. The results are sorted by
+// tenant so that the output remains stable. Once the context is done, no
+// tenant is started anymore and the remaining ones are returned as skipped.
+func forEachTenant(ctx context.Context, tenants []string, concurrency int, fn func(tenant string) (string, error)) ([]tenantResult, []string) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, concurrency)
+		results = make([]tenantResult, len(tenants))
+		started = len(tenants)
+		bar     = newProgress(len(tenants))
+	)
+	defer bar.finish()
+	for i, t := range tenants {
+		sem <- struct{}{}
+		if ctx.Err() != nil {
+			<-sem
+			started = i
+			break
+		}
+		wg.Add(1)
+		go func(i int, t string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			id, err := fn(t)
+			results[i] = tenantResult{tenant: t, id: id, err: err}
+			bar.inc()
+		}(i, t)
+	}
+	wg.Wait()
+
+	results = results[:started]
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].tenant < results[j].tenant
+	})
+	return results, tenants[started:]
+}
+
+// tenantConfig is an entry of a YAML or JSON Lines tenant file, allowing to
+// spread the tenants over several Alertmanagers.
+type tenantConfig struct {
+	Tenant         string `yaml:"tenant" json:"tenant"`
+	URL            string `yaml:"url,omitempty" json:"url,omitempty"`
+	HTTPConfigFile string `yaml:"http_config_file,omitempty" json:"http_config_file,omitempty"`
+	MaxDuration    string `yaml:"max_duration,omitempty" json:"max_duration,omitempty"`
+	// Header overrides --tenant.http-header for the tenant.
+	Header string `yaml:"header,omitempty" json:"header,omitempty"`
+	// Comment overrides the comment template of silence add for the tenant.
+	Comment string `yaml:"comment,omitempty" json:"comment,omitempty"`
+
+	url         *url.URL
+	maxDuration model.Duration
+}
+
+func readTenantFromFile(tenantFile, column, csvHeaderMode string) ([]string, map[string]tenantConfig, error) {
+	var (
+		source = fmt.Sprintf("tenant file '%s'", tenantFile)
+		b      []byte
+		err    error
+	)
+	if tenantFile == "-" {
+		source = "stdin"
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(tenantFile)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to read %s: %v", source, err)
+	}
+
+	var (
+		tenants []string
+		configs map[string]tenantConfig
+	)
+	switch {
+	case isYAMLTenantFile(tenantFile, b):
+		tenants, configs, err = readTenantConfigs(b)
+	case isJSONLTenantFile(b):
+		tenants, configs, err = readJSONLTenantConfigs(b)
+	case isCSVTenantFile(tenantFile, b, column, csvHeaderMode):
+		tenants, err = readCSVTenants(b, column, csvHeaderMode)
+	default:
+		tenants, err = readTenants(bytes.NewReader(b))
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("Unable to read %s: %v", source, err)
+	}
+	if len(tenants) < 1 {
+		return nil, nil, fmt.Errorf("no tenant found in %s", source)
+	}
+	return tenants, configs, nil
+}
+
+// isYAMLTenantFile reports whether the tenant file is a YAML list of tenant
+// configs, either from its extension or its first line.
+func isYAMLTenantFile(tenantFile string, b []byte) bool {
+	if ext := filepath.Ext(tenantFile); ext == ".yml" || ext == ".yaml" {
+		return true
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return strings.HasPrefix(line, "-")
+	}
+	return false
+}
+
+// isCSVTenantFile reports whether the tenant file is a CSV file, either from
+// its extension, --tenant.csv-header=always or its first line being a header
+// naming the tenant column.
+func isCSVTenantFile(tenantFile string, b []byte, column, headerMode string) bool {
+	switch {
+	case filepath.Ext(tenantFile) == ".csv" || headerMode == "always":
+		return true
+	case headerMode == "never":
+		return false
+	}
+	fields, ok := firstCSVRecord(b)
+	return ok && csvHeader(fields, column)
+}
+
+// firstCSVRecord returns the fields of the first line of a CSV tenant file,
+// skipping the blank lines and the comments.
+func firstCSVRecord(b []byte) ([]string, bool) {
+	r := newCSVReader(b)
+	fields, err := r.Read()
+	return fields, err == nil
+}
+
+func newCSVReader(b []byte) *csv.Reader {
+	r := csv.NewReader(bytes.NewReader(b))
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	return r
+}
+
+// csvHeader reports whether the fields of a CSV line are a header, having
+// several columns one of which is 'tenant' or the column given by name.
+func csvHeader(fields []string, column string) bool {
+	if len(fields) < 2 {
+		return false
+	}
+	for _, f := range fields {
+		f = strings.TrimSpace(f)
+		if strings.EqualFold(f, "tenant") || (column != "" && f == column) {
+			return true
+		}
+	}
+	return false
+}
+
+// readCSVTenants returns the tenants of the given column of a CSV tenant
+// file, the first one by default. The header, if any, is skipped and names
+// the columns. It's detected by csvHeader unless headerMode is always or
+// never.
+func readCSVTenants(b []byte, column, headerMode string) ([]string, error) {
+	var (
+		r       = newCSVReader(b)
+		tenants []string
+		index   int
+	)
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if err == io.EOF {
+			return tenants, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			header := headerMode == "always" || headerMode != "never" && csvHeader(record, column)
+			if column != "" {
+				if index, err = csvColumn(record, header, column); err != nil {
+					return nil, err
+				}
+			}
+			if header {
+				continue
+			}
+		}
+		if index >= len(record) {
+			line, _ := r.FieldPos(0)
+			return nil, fmt.Errorf("no column %d at line %d", index+1, line)
+		}
+		if tenant := strings.TrimSpace(record[index]); tenant != "" {
+			tenants = append(tenants, tenant)
+		}
+	}
+}
+
+// csvColumn returns the index of the column given by its position from 1, or
+// by its name in the header.
+func csvColumn(first []string, header bool, column string) (int, error) {
+	if n, err := strconv.Atoi(column); err == nil {
+		if n < 1 {
+			return 0, fmt.Errorf("invalid tenant column '%s', positions start at 1", column)
+		}
+		return n - 1, nil
+	}
+	if header {
+		for i, f := range first {
+			if strings.TrimSpace(f) == column {
+				return i, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("no '%s' column in the CSV header", column)
+}
+
+// readTenantConfigs returns the tenants of a YAML tenant file, and their
+// configs by tenant.
+func readTenantConfigs(b []byte) ([]string, map[string]tenantConfig, error) {
+	var entries []tenantConfig
+	if err := yaml.UnmarshalStrict(b, &entries); err != nil {
+		return nil, nil, err
+	}
+
+	tenants := make([]string, 0, len(entries))
+	configs := make(map[string]tenantConfig, len(entries))
+	for _, tc := range entries {
+		if err := addTenantConfig(configs, &tc); err != nil {
+			return nil, nil, err
+		}
+		tenants = append(tenants, tc.Tenant)
+	}
+	return tenants, configs, nil
+}
+
+// isJSONLTenantFile reports whether the tenant file is made of JSON Lines,
+// from its first line being a JSON object.
+func isJSONLTenantFile(b []byte) bool {
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return isJSONObject(line)
+	}
+	return false
+}
+
+// isJSONObject reports whether the line is a JSON object, null and the other
+// JSON values not being tenant configs.
+func isJSONObject(line string) bool {
+	var v map[string]interface{}
+	return json.Unmarshal([]byte(line), &v) == nil && v != nil
+}
+
+// readJSONLTenantConfigs returns the tenants of a JSON Lines tenant file, a
+// tenant config per line, and their configs by tenant.
+func readJSONLTenantConfigs(b []byte) ([]string, map[string]tenantConfig, error) {
+	var (
+		tenants []string
+		configs = map[string]tenantConfig{}
+	)
+	for i, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !isJSONObject(line) {
+			return nil, nil, fmt.Errorf("invalid tenant config at line %d: not a JSON object", i+1)
+		}
+		var tc tenantConfig
+		d := json.NewDecoder(strings.NewReader(line))
+		d.DisallowUnknownFields()
+		if err := d.Decode(&tc); err != nil {
+			return nil, nil, fmt.Errorf("invalid tenant config at line %d: %v", i+1, err)
+		}
+		if err := addTenantConfig(configs, &tc); err != nil {
+			return nil, nil, fmt.Errorf("invalid tenant config at line %d: %v", i+1, err)
+		}
+		tenants = append(tenants, tc.Tenant)
+	}
+	return tenants, configs, nil
+}
+
+// addTenantConfig validates the tenant config and adds it to configs.
+func addTenantConfig(configs map[string]tenantConfig, tc *tenantConfig) error {
+	if strings.TrimSpace(tc.Tenant) == "" {
+		return errors.New("missing tenant in tenant config")
+	}
+	if _, ok := configs[tc.Tenant]; ok {
+		return fmt.Errorf("duplicate tenant '%s'", tc.Tenant)
+	}
+	if tc.URL != "" {
+		u, err := url.Parse(tc.URL)
+		if err != nil {
+			return fmt.Errorf("invalid url for '%s' tenant: %v", tc.Tenant, err)
+		}
+		if err := checkAMURL(u); err != nil {
+			return fmt.Errorf("invalid url for '%s' tenant: %v", tc.Tenant, err)
+		}
+		tc.url = u
+	}
+	if tc.HTTPConfigFile != "" {
+		if _, _, err := promconfig.LoadHTTPConfigFile(tc.HTTPConfigFile); err != nil {
+			return fmt.Errorf("invalid http_config_file for '%s' tenant: %v", tc.Tenant, err)
+		}
+	}
+	if tc.MaxDuration != "" {
+		md, err := parseMaxDuration(tc.MaxDuration)
+		if err != nil {
+			return fmt.Errorf("invalid max_duration for '%s' tenant: %v", tc.Tenant, err)
+		}
+		tc.maxDuration = md
+	}
+	if strings.ContainsAny(tc.Header, " \t:") {
+		return fmt.Errorf("invalid header for '%s' tenant: '%s'", tc.Tenant, tc.Header)
+	}
+	configs[tc.Tenant] = *tc
+	return nil
+}
+
+// readTenants returns the tenant IDs, one per line. Surrounding whitespaces
+// are trimmed, blank lines and lines starting with '#' are skipped.
+func readTenants(r io.Reader) ([]string, error) {
+	var tenants []string
+
+	fileScanner := bufio.NewScanner(r)
+	fileScanner.Split(bufio.ScanLines)
+	for fileScanner.Scan() {
+		tenant := strings.TrimSpace(fileScanner.Text())
+		if tenant == "" || strings.HasPrefix(tenant, "#") {
+			continue
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, fileScanner.Err()
+}
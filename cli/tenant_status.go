@@ -0,0 +1,142 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// readTenantsFromStatus returns the tenants listed by the status endpoint at
+// path, resolved against the Alertmanager URLs which are tried in turn. The
+// endpoint returns a JSON list of tenants, a JSON object with a 'tenants'
+// list, or a tenant per line as text/plain.
+func readTenantsFromStatus(path string) ([]string, error) {
+	if path == "" {
+		return nil, errors.New("tenant.from-status needs --tenant.status-path, the path of the endpoint listing the tenants")
+	}
+	ref, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --tenant.status-path: %v", err)
+	}
+
+	httpConfig, err := NewAlertmanagerClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := newHTTPClient(*httpConfig)
+	if err != nil {
+		return nil, err
+	}
+	client.Timeout = timeout
+	// The tenants are listed before the command runs, don't let the listing
+	// outlast its deadline.
+	ctx := context.Background()
+	if deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, deadline)
+		defer cancel()
+	}
+	for i, u := range alertmanagerURLs {
+		var tenants []string
+		err = withRetry(ctx, "list tenants", func() (err error) {
+			tenants, err = getStatusTenants(ctx, client, statusURL(u, ref), u.User)
+			return err
+		})
+		if err == nil {
+			return tenants, nil
+		}
+		if i < len(alertmanagerURLs)-1 {
+			logger.Warn("Unable to list the tenants, trying the next Alertmanager", "url", u.Redacted(), "err", err)
+		}
+	}
+	return nil, fmt.Errorf("Unable to list the tenants from the status endpoint, use --tenant.file instead: %v", err)
+}
+
+// statusURL returns the URL of the status endpoint, ref being relative to the
+// path of the Alertmanager URL unless it starts with '/'.
+func statusURL(u, ref *url.URL) *url.URL {
+	base := *u
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+	}
+	return base.ResolveReference(ref)
+}
+
+// getStatusTenants gets the tenants listed at u.
+func getStatusTenants(ctx context.Context, client *http.Client, u *url.URL, user *url.Userinfo) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json, text/plain")
+	if user != nil {
+		password, _ := user.Password()
+		req.SetBasicAuth(user.Username(), password)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{url: u.Redacted(), code: resp.StatusCode}
+	}
+
+	var tenants []string
+	if err := json.Unmarshal(b, &tenants); err == nil {
+		return tenants, nil
+	}
+	var list struct {
+		Tenants []string `json:"tenants"`
+	}
+	if err := json.Unmarshal(b, &list); err == nil && list.Tenants != nil {
+		return list.Tenants, nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType == "text/plain" {
+		return readTenants(bytes.NewReader(b))
+	}
+	return nil, fmt.Errorf("GET %s didn't return a list of tenants", u.Redacted())
+}
+
+// statusError is the HTTP error of the status endpoint. It's a
+// runtime.ClientResponseStatus, so that only the server errors are retried.
+type statusError struct {
+	url  string
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("GET %s: %d %s", e.url, e.code, http.StatusText(e.code))
+}
+
+func (e *statusError) IsSuccess() bool     { return e.code/100 == 2 }
+func (e *statusError) IsRedirect() bool    { return e.code/100 == 3 }
+func (e *statusError) IsClientError() bool { return e.code/100 == 4 }
+func (e *statusError) IsServerError() bool { return e.code/100 == 5 }
+func (e *statusError) IsCode(code int) bool {
+	return e.code == code
+}
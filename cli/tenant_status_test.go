@@ -0,0 +1,278 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStatusEndpoint is an HTTP server listing the tenants at a single path.
+type fakeStatusEndpoint struct {
+	*httptest.Server
+
+	mtx      sync.Mutex
+	requests []*http.Request
+}
+
+// newFakeStatusEndpoint starts a status endpoint replying to the GET
+// requests of path with the content type and body, and 404 otherwise. The
+// Alertmanager URL is set to its URL with the base path.
+func newFakeStatusEndpoint(t *testing.T, basePath, path, contentType, body string) *fakeStatusEndpoint {
+	t.Helper()
+	e := &fakeStatusEndpoint{}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		e.mtx.Lock()
+		e.requests = append(e.requests, r)
+		e.mtx.Unlock()
+		if r.Method != http.MethodGet || r.URL.Path != path {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(e.Close)
+
+	u, err := url.Parse(e.URL + basePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := alertmanagerURLs
+	alertmanagerURLs = urlListValue{u}
+	t.Cleanup(func() { alertmanagerURLs = old })
+	return e
+}
+
+func (e *fakeStatusEndpoint) received() []*http.Request {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+	return append([]*http.Request(nil), e.requests...)
+}
+
+func TestReadTenantsFromStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		basePath    string
+		statusPath  string
+		path        string
+		contentType string
+		body        string
+		tenants     []string
+		err         string
+	}{
+		{
+			name:        "JSON list",
+			statusPath:  "/admin/tenants",
+			path:        "/admin/tenants",
+			contentType: "application/json",
+			body:        `["tenant-a","tenant-b"]`,
+			tenants:     []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:        "JSON object",
+			statusPath:  "/admin/tenants",
+			path:        "/admin/tenants",
+			contentType: "application/json",
+			body:        `{"status":"success","tenants":["tenant-a","tenant-b"]}`,
+			tenants:     []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:        "text",
+			statusPath:  "/admin/tenants",
+			path:        "/admin/tenants",
+			contentType: "text/plain; charset=utf-8",
+			body:        "# tenants\ntenant-a\n\ntenant-b\n",
+			tenants:     []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:        "path relative to the Alertmanager URL",
+			basePath:    "/alertmanager",
+			statusPath:  "api/v1/tenants",
+			path:        "/alertmanager/api/v1/tenants",
+			contentType: "application/json",
+			body:        `["tenant-a"]`,
+			tenants:     []string{"tenant-a"},
+		},
+		{
+			name:        "absolute path",
+			basePath:    "/alertmanager",
+			statusPath:  "/admin/tenants",
+			path:        "/admin/tenants",
+			contentType: "application/json",
+			body:        `["tenant-a"]`,
+			tenants:     []string{"tenant-a"},
+		},
+		{
+			name:        "not found",
+			statusPath:  "/admin/tenants",
+			path:        "/other",
+			contentType: "application/json",
+			err:         "Unable to list the tenants from the status endpoint, use --tenant.file instead: GET " + "<url>/admin/tenants: 404 Not Found",
+		},
+		{
+			name:        "not a list of tenants",
+			statusPath:  "/admin/tenants",
+			path:        "/admin/tenants",
+			contentType: "text/html",
+			body:        "<html></html>",
+			err:         "didn't return a list of tenants",
+		},
+		{
+			name: "no path",
+			err:  "tenant.from-status needs --tenant.status-path",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			e := newFakeStatusEndpoint(t, tc.basePath, tc.path, tc.contentType, tc.body)
+			tenants, err := readTenantsFromStatus(tc.statusPath)
+			if tc.err != "" {
+				expected := strings.ReplaceAll(tc.err, "<url>", e.URL)
+				if err == nil || !strings.Contains(err.Error(), expected) {
+					t.Fatalf("expected an error containing %q, got %v", expected, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tenants, tc.tenants) {
+				t.Fatalf("expected tenants %q, got %q", tc.tenants, tenants)
+			}
+		})
+	}
+}
+
+func TestReadTenantsFromStatusBasicAuth(t *testing.T) {
+	e := newFakeStatusEndpoint(t, "", "/admin/tenants", "application/json", `["tenant-a"]`)
+	u, err := url.Parse(e.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.User = url.UserPassword("atm", "secret")
+	alertmanagerURLs = urlListValue{u}
+
+	if _, err := readTenantsFromStatus("/admin/tenants"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user, password, ok := e.received()[0].BasicAuth(); !ok || user != "atm" || password != "secret" {
+		t.Fatalf("expected the basic auth of the Alertmanager URL, got %q %q", user, password)
+	}
+}
+
+func TestReadTenantsFromStatusFailover(t *testing.T) {
+	captureLogs(t)
+	down := newFakeStatusEndpoint(t, "", "/other", "application/json", "")
+	up := newFakeStatusEndpoint(t, "", "/admin/tenants", "application/json", `["tenant-a"]`)
+	downURL, _ := url.Parse(down.URL)
+	upURL, _ := url.Parse(up.URL)
+	alertmanagerURLs = urlListValue{downURL, upURL}
+
+	tenants, err := readTenantsFromStatus("/admin/tenants")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"tenant-a"}; !reflect.DeepEqual(tenants, expected) {
+		t.Fatalf("expected tenants %q, got %q", expected, tenants)
+	}
+	if len(down.received()) != 1 || len(up.received()) != 1 {
+		t.Fatalf("expected a request to each Alertmanager, got %d and %d", len(down.received()), len(up.received()))
+	}
+}
+
+func TestTenantFromStatus(t *testing.T) {
+	e := newFakeStatusEndpoint(t, "", "/admin/tenants", "application/json", `["prod-a","prod-b","dev-a"]`)
+
+	tf := testTenantFlags()
+	tf.tenantFromStatus, tf.tenantStatusPath, tf.tenantRegex = true, "/admin/tenants", "prod-.*"
+	captureLogs(t)
+	for i := 0; i < 2; i++ {
+		tenants, err := tf.tenants()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected := []string{"prod-a", "prod-b"}; !reflect.DeepEqual(tenants, expected) {
+			t.Fatalf("expected tenants %q, got %q", expected, tenants)
+		}
+	}
+	// The tenants are cached for the run.
+	if n := len(e.received()); n != 1 {
+		t.Fatalf("expected a single request to the status endpoint, got %d", n)
+	}
+	if !tf.multiTenant() {
+		t.Fatal("expected the tenants from the status endpoint to be several tenants")
+	}
+}
+
+func TestTenantFromStatusErrors(t *testing.T) {
+	newFakeStatusEndpoint(t, "", "/admin/tenants", "application/json", `[]`)
+	for _, tc := range []struct {
+		name string
+		tf   func(*tenantFlags)
+		err  string
+	}{
+		{
+			name: "empty list",
+			tf:   func(tf *tenantFlags) {},
+			err:  "no tenant listed by the status endpoint",
+		},
+		{
+			name: "with tenant",
+			tf:   func(tf *tenantFlags) { tf.tenant = "tenant-a" },
+			err:  "tenant.from-status is mutually exclusive with tenant and tenant.file",
+		},
+		{
+			name: "with tenant file",
+			tf:   func(tf *tenantFlags) { tf.tenantFile = "tenants.txt" },
+			err:  "tenant.from-status is mutually exclusive with tenant and tenant.file",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tf := testTenantFlags()
+			tf.tenantFromStatus, tf.tenantStatusPath = true, "/admin/tenants"
+			tc.tf(&tf)
+			if _, err := tf.tenants(); err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestSilenceCountFromStatus(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	am.addSilence("tenant-b", newTestSilence(t, time.Hour, "alertname=foo"))
+	// The fake Alertmanager lists its tenants like a Mimir admin endpoint.
+	am.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/admin/tenants" {
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("tenant-a\ntenant-b\n"))
+			return
+		}
+		am.serveHTTP(w, r)
+	})
+
+	stdout, _, err := runCommand(t, "silence", "count", "--tenant.from-status", "--tenant.status-path", "/admin/tenants")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "tenant-a 0\ntenant-b 1\n"; stdout != expected {
+		t.Fatalf("expected %q, got %q", expected, stdout)
+	}
+}
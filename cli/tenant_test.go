@@ -0,0 +1,797 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	promconfig "github.com/prometheus/common/config"
+
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+)
+
+// writeTenantFile writes the content to a file named name in a temporary
+// directory, and returns its path.
+func writeTenantFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadTenantFromFile(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		tenants []string
+	}{
+		{
+			name:    "trailing newline",
+			content: "tenant-a\ntenant-b\n",
+			tenants: []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:    "no trailing newline",
+			content: "tenant-a\ntenant-b",
+			tenants: []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:    "CRLF line endings",
+			content: "tenant-a\r\ntenant-b\r\n",
+			tenants: []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:    "blank lines, comments and whitespaces",
+			content: "# production tenants\n\n  tenant-a  \n\t\n#tenant-c\ntenant-b\n\n",
+			tenants: []string{"tenant-a", "tenant-b"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tenants, _, err := readTenantFromFile(writeTenantFile(t, "tenants.txt", tc.content), "", "auto")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tenants, tc.tenants) {
+				t.Fatalf("expected tenants %q, got %q", tc.tenants, tenants)
+			}
+		})
+	}
+}
+
+func TestReadTenantFromFileNoTenant(t *testing.T) {
+	for _, content := range []string{"", "\n\n", "# no tenant yet\n\r\n  \n"} {
+		_, _, err := readTenantFromFile(writeTenantFile(t, "tenants.txt", content), "", "auto")
+		if err == nil || !strings.Contains(err.Error(), "no tenant found") {
+			t.Fatalf("expected a no tenant error for %q, got %v", content, err)
+		}
+	}
+}
+
+func TestReadTenantFromCSVFile(t *testing.T) {
+	const headered = "tenant,env,owner\ntenant-a,prod,alice\ntenant-b,dev,bob\n"
+	for _, tc := range []struct {
+		name    string
+		file    string
+		content string
+		column  string
+		header  string
+		tenants []string
+		err     string
+	}{
+		{
+			name:    "header",
+			file:    "tenants.txt",
+			content: headered,
+			tenants: []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:    "header with comments and blank lines",
+			file:    "tenants.csv",
+			content: "# production tenants\n\ntenant, env\n tenant-a, prod\n\n#tenant-c,prod\ntenant-b,dev\n",
+			tenants: []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:    "column by name",
+			file:    "tenants.csv",
+			content: headered,
+			column:  "owner",
+			tenants: []string{"alice", "bob"},
+		},
+		{
+			name:    "column by position",
+			file:    "tenants.csv",
+			content: headered,
+			column:  "2",
+			tenants: []string{"prod", "dev"},
+		},
+		{
+			name:    "header naming the column",
+			file:    "tenants.txt",
+			content: "org,env\norg-1,prod\norg-2,dev\n",
+			column:  "org",
+			tenants: []string{"org-1", "org-2"},
+		},
+		{
+			name:    "header without the tenant column",
+			file:    "tenants.csv",
+			content: "org,team\norg-1,team-a\norg-2,team-b\n",
+			header:  "always",
+			tenants: []string{"org-1", "org-2"},
+		},
+		{
+			name:    "header without the tenant column, not a .csv file",
+			file:    "tenants.txt",
+			content: "org,team\norg-1,team-a\n",
+			header:  "always",
+			column:  "team",
+			tenants: []string{"team-a"},
+		},
+		{
+			name:    "no header, tenant first line",
+			file:    "tenants.csv",
+			content: "tenant,prod\ntenant-b,dev\n",
+			header:  "never",
+			tenants: []string{"tenant", "tenant-b"},
+		},
+		{
+			name:    "no header",
+			file:    "tenants.csv",
+			content: "tenant-a,prod\ntenant-b,dev\n",
+			tenants: []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:    "no header, column by position",
+			file:    "tenants.csv",
+			content: "tenant-a,prod\ntenant-b,dev\n",
+			column:  "2",
+			tenants: []string{"prod", "dev"},
+		},
+		{
+			name:    "empty cells",
+			file:    "tenants.csv",
+			content: "tenant,env\n,prod\ntenant-b,dev\n",
+			tenants: []string{"tenant-b"},
+		},
+		{
+			name:    "plain tenant file",
+			file:    "tenants.txt",
+			content: "tenant-a\ntenant-b\n",
+			tenants: []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:    "unknown column name",
+			file:    "tenants.csv",
+			content: headered,
+			column:  "team",
+			err:     "no 'team' column in the CSV header",
+		},
+		{
+			name:    "column by name without header",
+			file:    "tenants.csv",
+			content: "tenant-a,prod\n",
+			column:  "env",
+			err:     "no 'env' column in the CSV header",
+		},
+		{
+			name:    "column position from 1",
+			file:    "tenants.csv",
+			content: headered,
+			column:  "0",
+			err:     "positions start at 1",
+		},
+		{
+			name:    "missing column",
+			file:    "tenants.csv",
+			content: "tenant-a,prod\ntenant-b\n",
+			column:  "2",
+			err:     "no column 2 at line 2",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			header := tc.header
+			if header == "" {
+				header = "auto"
+			}
+			tenants, _, err := readTenantFromFile(writeTenantFile(t, tc.file, tc.content), tc.column, header)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tenants, tc.tenants) {
+				t.Fatalf("expected tenants %q, got %q", tc.tenants, tenants)
+			}
+		})
+	}
+}
+
+func TestReadTenantFromJSONLFile(t *testing.T) {
+	content := `# tenants with their overrides
+{"tenant": "tenant-a", "header": "X-Org", "comment": "maintenance of a"}
+
+{"tenant": "tenant-b"}
+`
+	tenants, configs, err := readTenantFromFile(writeTenantFile(t, "tenants.jsonl", content), "", "auto")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"tenant-a", "tenant-b"}; !reflect.DeepEqual(tenants, expected) {
+		t.Fatalf("expected tenants %q, got %q", expected, tenants)
+	}
+	if tc := configs["tenant-a"]; tc.Header != "X-Org" || tc.Comment != "maintenance of a" {
+		t.Fatalf("unexpected config for tenant-a: %+v", tc)
+	}
+	if tc := configs["tenant-b"]; tc.Header != "" || tc.Comment != "" {
+		t.Fatalf("unexpected config for tenant-b: %+v", tc)
+	}
+}
+
+func TestReadTenantFromJSONLFileInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "null",
+			content: "{\"tenant\": \"tenant-a\"}\nnull\n",
+			err:     "at line 2: not a JSON object",
+		},
+		{
+			name:    "scalar",
+			content: "{\"tenant\": \"tenant-a\"}\n\"tenant-b\"\n",
+			err:     "at line 2: not a JSON object",
+		},
+		{
+			name:    "array",
+			content: "{\"tenant\": \"tenant-a\"}\n[\"tenant-b\"]\n",
+			err:     "at line 2: not a JSON object",
+		},
+		{
+			name:    "missing tenant",
+			content: "{\"tenant\": \"tenant-a\"}\n# no tenant\n{\"header\": \"X-Org\"}\n",
+			err:     "at line 3: missing tenant",
+		},
+		{
+			name:    "empty tenant",
+			content: "{\"tenant\": \"tenant-a\"}\n{\"tenant\": \" \"}\n",
+			err:     "at line 2: missing tenant",
+		},
+		{
+			name:    "unknown field",
+			content: "{\"tenant\": \"tenant-a\", \"headers\": \"X-Org\"}\n",
+			err:     "at line 1: json: unknown field \"headers\"",
+		},
+		{
+			name:    "invalid JSON",
+			content: "{\"tenant\": \"tenant-a\"}\n{\"tenant\": \"tenant-b\"\n",
+			err:     "at line 2:",
+		},
+		{
+			name:    "duplicate tenant",
+			content: "{\"tenant\": \"tenant-a\"}\n{\"tenant\": \"tenant-a\"}\n",
+			err:     "at line 2: duplicate tenant 'tenant-a'",
+		},
+		{
+			name:    "invalid header",
+			content: "{\"tenant\": \"tenant-a\", \"header\": \"X-Org: 1\"}\n",
+			err:     "at line 1: invalid header for 'tenant-a' tenant",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := readTenantFromFile(writeTenantFile(t, "tenants.jsonl", tc.content), "", "auto")
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestReadTenantFromFileNotJSONL(t *testing.T) {
+	// A plain tenant file whose first tenant is a JSON value other than an
+	// object is not read as JSON Lines.
+	for _, content := range []string{"null\ntenant-b\n", "123\n456\n", "true\n"} {
+		tenants, _, err := readTenantFromFile(writeTenantFile(t, "tenants.txt", content), "", "auto")
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", content, err)
+		}
+		if expected := strings.Fields(content); !reflect.DeepEqual(tenants, expected) {
+			t.Fatalf("expected tenants %q, got %q", expected, tenants)
+		}
+	}
+}
+
+func TestTenantHeaderValue(t *testing.T) {
+	for _, tc := range []struct {
+		tenant, prefix, suffix, delimiter string
+		header                            string
+	}{
+		{tenant: "123", header: "123"},
+		{tenant: "123", prefix: "org-", header: "org-123"},
+		{tenant: "123", suffix: "-prod", header: "123-prod"},
+		{tenant: "123", prefix: "org-", suffix: "-prod", header: "org-123-prod"},
+		{tenant: "123|456", prefix: "org-", delimiter: "|", header: "org-123|org-456"},
+		{tenant: "123,456", prefix: "org-", suffix: "-prod", delimiter: ",", header: "org-123-prod,org-456-prod"},
+	} {
+		tf := testTenantFlags()
+		tf.tenantPrefix, tf.tenantSuffix = tc.prefix, tc.suffix
+		if tc.delimiter != "" {
+			tf.tenantDelimiter = tc.delimiter
+		}
+		if header := tf.headerValue(tc.tenant); header != tc.header {
+			t.Fatalf("expected header %q for %q, got %q", tc.header, tc.tenant, header)
+		}
+	}
+}
+
+func TestTenantEmptyDelimiter(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	_, _, err := runCommand(t, "silence", "query", "--tenant", "123,456", "--tenant.prefix", "org-", "--tenant.delimiter=")
+	if err == nil || err.Error() != "tenant.delimiter must not be empty" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(am.received()); n != 0 {
+		t.Fatalf("expected no request to be sent, got %d", n)
+	}
+}
+
+func TestTenantHeaderPrefix(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	tf := testTenantFlags()
+	tf.tenantPrefix = "org-"
+	tf.tenantFile = writeTenantFile(t, "tenants.txt", "123\n456\n")
+
+	// The prefix applies to the tenants of the tenant file and to --tenant
+	// alike.
+	tenants, err := tf.tenants()
+	if err != nil {
+		t.Fatal(err)
+	}
+	single := testTenantFlags()
+	single.tenantPrefix, single.tenant = "org-", "789"
+	for _, c := range []struct {
+		tf      tenantFlags
+		tenants []string
+	}{{tf, tenants}, {single, []string{"789"}}} {
+		for _, tenant := range c.tenants {
+			amclient := testClient(t, &c.tf, tenant)
+			if _, err := amclient.Silence.GetSilences(silence.NewGetSilencesParams().WithContext(context.Background())); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	var headers []string
+	for _, r := range am.received() {
+		headers = append(headers, r.tenant)
+	}
+	if expected := []string{"org-123", "org-456", "org-789"}; !reflect.DeepEqual(headers, expected) {
+		t.Fatalf("expected headers %q, got %q", expected, headers)
+	}
+}
+
+func TestForEachTenantCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tenants := []string{"tenant-a", "tenant-b", "tenant-c", "tenant-d"}
+
+	// The context is cancelled while tenant-b is processed, as when the
+	// deadline is reached, so that tenant-c and tenant-d are never started.
+	results, skipped := forEachTenant(ctx, tenants, 1, func(tenant string) (string, error) {
+		if tenant == "tenant-b" {
+			cancel()
+		}
+		return tenant, nil
+	})
+	var done []string
+	for _, r := range results {
+		done = append(done, r.tenant)
+	}
+	if expected := []string{"tenant-a", "tenant-b"}; !reflect.DeepEqual(done, expected) {
+		t.Fatalf("expected the tenants %q to be processed, got %q", expected, done)
+	}
+	if expected := []string{"tenant-c", "tenant-d"}; !reflect.DeepEqual(skipped, expected) {
+		t.Fatalf("expected the tenants %q to be skipped, got %q", expected, skipped)
+	}
+}
+
+func TestSkippedError(t *testing.T) {
+	dir := t.TempDir()
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldStderr, oldFile := os.Stderr, skippedTenantsFile
+	os.Stderr, skippedTenantsFile = stderr, filepath.Join(dir, "skipped.txt")
+	defer func() { os.Stderr, skippedTenantsFile = oldStderr, oldFile }()
+
+	err = skippedError([]string{"tenant-c", "tenant-d"})
+	stderr.Close()
+	if code := exitCode(err); code != exitDeadline {
+		t.Fatalf("expected exit code %d, got %d (%v)", exitDeadline, code, err)
+	}
+	if !strings.Contains(err.Error(), "2 tenant(s) skipped") {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	diag, err := os.ReadFile(stderr.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(diag), "tenant-c") || !strings.Contains(string(diag), "tenant-d") {
+		t.Fatalf("expected the skipped tenants on stderr, got %q", diag)
+	}
+
+	// The skipped tenants file is a tenant file, to re-run the command for
+	// them only.
+	tenants, _, err := readTenantFromFile(skippedTenantsFile, "", "auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"tenant-c", "tenant-d"}; !reflect.DeepEqual(tenants, expected) {
+		t.Fatalf("expected the skipped tenants %q in the file, got %q", expected, tenants)
+	}
+}
+
+func TestTenantHeaderConcurrent(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	var tenants []string
+	for i := 0; i < 64; i++ {
+		tenant := fmt.Sprintf("tenant-%02d", i)
+		tenants = append(tenants, tenant)
+		am.addSilence(tenant, newTestSilence(t, time.Hour, "tenant="+tenant))
+	}
+	tf := testTenantFlags()
+	httpConfig := testHTTPConfig(t)
+
+	// The tenants share the transport, each request has to carry the header
+	// of its own tenant to get the silence of the tenant back. Run with -race.
+	results, skipped := forEachTenant(context.Background(), tenants, 16, func(tenant string) (string, error) {
+		amclient, err := tf.newClient(httpConfig, tenant)
+		if err != nil {
+			return "", err
+		}
+		for i := 0; i < 5; i++ {
+			getOk, err := amclient.Silence.GetSilences(silence.NewGetSilencesParams().WithContext(context.Background()))
+			if err != nil {
+				return "", err
+			}
+			if len(getOk.Payload) != 1 || *getOk.Payload[0].Matchers[0].Value != tenant {
+				return "", fmt.Errorf("got the silences of another tenant: %v", getOk.Payload)
+			}
+		}
+		return "", nil
+	})
+	if len(skipped) > 0 {
+		t.Fatalf("unexpected skipped tenants %q", skipped)
+	}
+	for _, r := range results {
+		if r.err != nil {
+			t.Fatalf("tenant %s: %v", r.tenant, r.err)
+		}
+	}
+	if n := len(am.received()); n != len(tenants)*5 {
+		t.Fatalf("expected %d requests, got %d", len(tenants)*5, n)
+	}
+}
+
+func TestTransportHTTPConfig(t *testing.T) {
+	resetFlags()
+	t.Cleanup(resetFlags)
+	am := newFakeAlertmanager(t)
+	tf := testTenantFlags()
+
+	// The tenants of a run share the transport of the HTTP config, another
+	// HTTP config gets its own transport.
+	bearerToken = "first"
+	first := testHTTPConfig(t)
+	bearerToken = "second"
+	second := testHTTPConfig(t)
+	for _, tc := range []struct {
+		httpConfig *promconfig.HTTPClientConfig
+		tenant     string
+	}{
+		{first, "tenant-a"},
+		{first, "tenant-b"},
+		{second, "tenant-a"},
+	} {
+		amclient, err := tf.newClient(tc.httpConfig, tc.tenant)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if _, err := amclient.Silence.GetSilences(silence.NewGetSilencesParams().WithContext(context.Background())); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	var auths []string
+	for _, r := range am.received() {
+		auths = append(auths, r.auth)
+	}
+	if expected := []string{"Bearer first", "Bearer first", "Bearer second"}; !reflect.DeepEqual(auths, expected) {
+		t.Fatalf("expected the authorizations %q, got %q", expected, auths)
+	}
+	transportsMtx.Lock()
+	defer transportsMtx.Unlock()
+	if len(transports) != 2 {
+		t.Fatalf("expected 2 transports, got %d", len(transports))
+	}
+}
+
+func TestTenantRegex(t *testing.T) {
+	tenantFile := writeTenantFile(t, "tenants.txt", "prod-a\nprod-b\nprod-canary\ndev-a\ndev-b\n")
+	for _, tc := range []struct {
+		name             string
+		include, exclude string
+		tenants          []string
+		err              string
+	}{
+		{
+			name:    "include",
+			include: "prod-.*",
+			tenants: []string{"prod-a", "prod-b", "prod-canary"},
+		},
+		{
+			name:    "include is anchored",
+			include: "a",
+			err:     "none of the 5 tenants matches the tenant regexes",
+		},
+		{
+			name:    "exclude",
+			exclude: ".*-canary",
+			tenants: []string{"prod-a", "prod-b", "dev-a", "dev-b"},
+		},
+		{
+			name:    "include and exclude",
+			include: "prod-.*",
+			exclude: ".*-canary|.*-b",
+			tenants: []string{"prod-a"},
+		},
+		{
+			name:    "invalid include",
+			include: "prod-(",
+			err:     "invalid --tenant.regex",
+		},
+		{
+			name:    "invalid exclude",
+			exclude: "[",
+			err:     "invalid --tenant.exclude-regex",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			logs := captureLogs(t)
+			tf := testTenantFlags()
+			tf.tenantFile, tf.tenantRegex, tf.tenantExclude = tenantFile, tc.include, tc.exclude
+			tenants, err := tf.tenants()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tenants, tc.tenants) {
+				t.Fatalf("expected tenants %q, got %q", tc.tenants, tenants)
+			}
+			if expected := fmt.Sprintf("matched=%d total=5", len(tc.tenants)); !strings.Contains(logs.String(), expected) {
+				t.Fatalf("expected the logs to contain %q, got %q", expected, logs.String())
+			}
+		})
+	}
+}
+
+func TestTenantRegexWithoutTenantFile(t *testing.T) {
+	tf := testTenantFlags()
+	tf.tenant, tf.tenantRegex = "tenant-a", "tenant-.*"
+	if _, err := tf.tenants(); err == nil || !strings.Contains(err.Error(), "only filter the tenants of a tenant file") {
+		t.Fatalf("expected an error, got %v", err)
+	}
+}
+
+func TestTenantWindow(t *testing.T) {
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\ntenant-c\ntenant-d\ntenant-e\n")
+	for _, tc := range []struct {
+		name          string
+		offset, limit int
+		regex         string
+		tenants       []string
+		err           string
+	}{
+		{
+			name:    "limit",
+			limit:   2,
+			tenants: []string{"tenant-a", "tenant-b"},
+		},
+		{
+			name:    "offset",
+			offset:  3,
+			tenants: []string{"tenant-d", "tenant-e"},
+		},
+		{
+			name:    "offset and limit",
+			offset:  1,
+			limit:   2,
+			tenants: []string{"tenant-b", "tenant-c"},
+		},
+		{
+			name:    "limit beyond the tenants",
+			offset:  4,
+			limit:   10,
+			tenants: []string{"tenant-e"},
+		},
+		{
+			name:    "after filtering",
+			regex:   "tenant-[ace]",
+			offset:  1,
+			limit:   1,
+			tenants: []string{"tenant-c"},
+		},
+		{
+			name:   "offset beyond the tenants",
+			offset: 5,
+			err:    "--tenant.offset 5 is beyond the 5 tenants",
+		},
+		{
+			name:   "offset beyond the filtered tenants",
+			regex:  "tenant-[ab]",
+			offset: 2,
+			err:    "--tenant.offset 2 is beyond the 2 tenants",
+		},
+		{
+			name:   "negative offset",
+			offset: -1,
+			err:    "invalid --tenant.offset -1",
+		},
+		{
+			name:  "negative limit",
+			limit: -1,
+			err:   "invalid --tenant.limit -1",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			captureLogs(t)
+			tf := testTenantFlags()
+			tf.tenantFile, tf.tenantRegex = tenantFile, tc.regex
+			tf.tenantOffset, tf.tenantLimit = tc.offset, tc.limit
+			tenants, err := tf.tenants()
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected an error containing %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tenants, tc.tenants) {
+				t.Fatalf("expected tenants %q, got %q", tc.tenants, tenants)
+			}
+		})
+	}
+}
+
+func TestTenantWindowBatches(t *testing.T) {
+	// Batches of 2 from offset 0 cover all the tenants exactly once.
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\ntenant-c\ntenant-d\ntenant-e\n")
+	captureLogs(t)
+	var all []string
+	for offset := 0; offset < 5; offset += 2 {
+		tf := testTenantFlags()
+		tf.tenantFile, tf.tenantOffset, tf.tenantLimit = tenantFile, offset, 2
+		tenants, err := tf.tenants()
+		if err != nil {
+			t.Fatalf("unexpected error at offset %d: %v", offset, err)
+		}
+		all = append(all, tenants...)
+	}
+	if expected := []string{"tenant-a", "tenant-b", "tenant-c", "tenant-d", "tenant-e"}; !reflect.DeepEqual(all, expected) {
+		t.Fatalf("expected tenants %q, got %q", expected, all)
+	}
+}
+
+func TestTenantWindowWithoutTenantFile(t *testing.T) {
+	tf := testTenantFlags()
+	tf.tenant, tf.tenantLimit = "tenant-a", 1
+	if _, err := tf.tenants(); err == nil || !strings.Contains(err.Error(), "only apply to the tenants of a tenant file") {
+		t.Fatalf("expected an error, got %v", err)
+	}
+}
+
+func TestHTTPConfigDir(t *testing.T) {
+	am := newFakeAlertmanager(t)
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"tenant-a.yml": "bearer_token: token-a\n",
+		"tenant-b.yml": "bearer_token: token-b\n",
+		// Only the .yml files are the HTTP configs of the tenants.
+		"tenant-c.yaml": "bearer_token: token-c\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	global := writeTenantFile(t, "http.yml", "bearer_token: global-token\n")
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\ntenant-c\n")
+
+	if _, _, err := runCommand(t, "--http.config.dir", dir, "--http.config.file", global, "silence", "query", "--tenant.file", tenantFile); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	auths := map[string]string{}
+	for _, r := range am.received() {
+		auths[r.tenant] = r.auth
+	}
+	expected := map[string]string{
+		"tenant-a": "Bearer token-a",
+		"tenant-b": "Bearer token-b",
+		"tenant-c": "Bearer global-token",
+	}
+	if !reflect.DeepEqual(auths, expected) {
+		t.Fatalf("expected the authorizations %q, got %q", expected, auths)
+	}
+}
+
+func TestHTTPConfigDirInvalid(t *testing.T) {
+	newFakeAlertmanager(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tenant-b.yml"), []byte("bearer_token: [\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tenantFile := writeTenantFile(t, "tenants.txt", "tenant-a\ntenant-b\n")
+
+	_, _, err := runCommand(t, "--http.config.dir", dir, "silence", "query", "--tenant.file", tenantFile)
+	if err == nil || !strings.Contains(err.Error(), "for 'tenant-b' tenant") {
+		t.Fatalf("expected an error naming tenant-b, got %v", err)
+	}
+}
+
+func TestHTTPConfigFileOfTenant(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"tenant-a.yml", "override.yml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer func(old string) { httpConfigDir = old }(httpConfigDir)
+	httpConfigDir = dir
+
+	tf := testTenantFlags()
+	tf.configs = map[string]tenantConfig{"tenant-b": {Tenant: "tenant-b", HTTPConfigFile: "/etc/atm/tenant-b.yml"}}
+	for tenant, expected := range map[string]string{
+		"tenant-a": filepath.Join(dir, "tenant-a.yml"),
+		// The http_config_file of the tenant config takes precedence.
+		"tenant-b":                               "/etc/atm/tenant-b.yml",
+		"tenant-c":                               "",
+		"":                                       "",
+		"../" + filepath.Base(dir) + "/tenant-a": "",
+		"..":                                     "",
+	} {
+		if file := tf.httpConfigFile(tenant); file != expected {
+			t.Fatalf("expected the HTTP config file %q for %q tenant, got %q", expected, tenant, file)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"net/http"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+var (
+	requestLimiter     *rate.Limiter
+	requestLimiterOnce sync.Once
+)
+
+// getRequestLimiter returns the token bucket limiter shared by all the
+// clients, so that the requests rate is bounded whatever the number of
+// tenants processed in parallel. It returns nil when --rps is not set.
+func getRequestLimiter() *rate.Limiter {
+	requestLimiterOnce.Do(func() {
+		if rps > 0 {
+			requestLimiter = rate.NewLimiter(rate.Limit(rps), 1)
+		}
+	})
+	return requestLimiter
+}
+
+// limitedRoundTripper waits for the limiter before sending each request.
+type limitedRoundTripper struct {
+	limiter *rate.Limiter
+	rt      http.RoundTripper
+}
+
+func (l *limitedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := l.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	return l.rt.RoundTrip(req)
+}
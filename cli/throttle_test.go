@@ -0,0 +1,111 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
+)
+
+// setRPS sets --rps for the test, resetting the shared limiter.
+func setRPS(t *testing.T, r float64) {
+	t.Helper()
+	reset := func(r float64) {
+		rps = r
+		requestLimiter = nil
+		requestLimiterOnce = sync.Once{}
+	}
+	old := rps
+	reset(r)
+	t.Cleanup(func() { reset(old) })
+}
+
+func TestRequestLimiterDisabled(t *testing.T) {
+	setRPS(t, 0)
+	if l := getRequestLimiter(); l != nil {
+		t.Fatalf("expected no limiter without --rps, got %v", l.Limit())
+	}
+}
+
+func TestRequestLimiter(t *testing.T) {
+	const (
+		limit    = 50
+		workers  = 8
+		requests = 40
+	)
+	setRPS(t, limit)
+
+	var (
+		mtx  sync.Mutex
+		seen []time.Time
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mtx.Lock()
+		seen = append(seen, time.Now())
+		mtx.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	// Each worker has its own client, like the tenants processed in parallel,
+	// the limiter being shared by all of them.
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		amclient := newTestClient(t, srv)
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := i; j < requests; j += workers {
+				params := silence.NewGetSilencesParams().WithContext(context.Background())
+				if _, err := amclient.Silence.GetSilences(params); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(seen) != requests {
+		t.Fatalf("expected %d requests, got %d", requests, len(seen))
+	}
+	sort.Slice(seen, func(i, j int) bool { return seen[i].Before(seen[j]) })
+	// The bucket holds a single token, so the first request is sent right
+	// away and the next ones one every 1/limit second.
+	elapsed := seen[len(seen)-1].Sub(seen[0])
+	if observed := float64(requests-1) / elapsed.Seconds(); observed > limit*1.05 {
+		t.Fatalf("expected at most %d requests per second, observed %.1f", limit, observed)
+	}
+}
+
+func TestMaxRateAlias(t *testing.T) {
+	setRPS(t, 0)
+	f := newApp().GetFlag("max-rate")
+	if f == nil {
+		t.Fatal("expected a --max-rate flag")
+	}
+	if err := f.Model().Value.Set("5"); err != nil {
+		t.Fatal(err)
+	}
+	if rps != 5 {
+		t.Fatalf("expected --max-rate to set --rps to 5, got %v", rps)
+	}
+}
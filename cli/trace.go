@@ -0,0 +1,49 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	httpTrace bool
+
+	// traceID numbers the traced requests of the run.
+	traceID atomic.Int64
+)
+
+// tracingRoundTripper logs every request sent and its response. As it wraps
+// the transport, each retry and redirect is logged as a request of its own,
+// with an ID correlating it to its response across the parallel tenants.
+type tracingRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func (t *tracingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := traceID.Add(1)
+	logger.Info("HTTP request", "id", id, "method", req.Method, "url", req.URL.String())
+
+	start := time.Now()
+	resp, err := t.rt.RoundTrip(req)
+	latency := time.Since(start).Round(time.Microsecond).String()
+	if err != nil {
+		logger.Info("HTTP request failed", "id", id, "latency", latency, "err", err)
+		return nil, err
+	}
+	logger.Info("HTTP response", "id", id, "status", resp.StatusCode, "latency", latency)
+	return resp, nil
+}
@@ -14,14 +14,316 @@
 package cli
 
 import (
+	"bufio"
 	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/common/model"
 
+	"github.com/prometheus/alertmanager/api/v2/client"
+	"github.com/prometheus/alertmanager/api/v2/client/silence"
 	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/matchers/compat"
 	"github.com/prometheus/alertmanager/pkg/labels"
 )
 
+// parseMatchers parses the matcher groups given on the command line.
+func parseMatchers(args []string) ([]labels.Matcher, error) {
+	if len(args) > 0 {
+		// If the parser fails then we likely don't have a (=|=~|!=|!~) so lets
+		// assume that the user wants alertname=<arg> and prepend `alertname=`
+		// to the front.
+		_, err := compat.Matcher(args[0], "cli")
+		if err != nil {
+			args[0] = fmt.Sprintf("alertname=%s", strconv.Quote(args[0]))
+		}
+	}
+
+	matchers := make([]labels.Matcher, 0, len(args))
+	for _, s := range args {
+		m, err := compat.Matcher(s, "cli")
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, *m)
+	}
+	return matchers, nil
+}
+
+// readLabelFile returns the matchers of a properties file of labels, with a
+// 'name=value' equality or a 'name=~regex' regex matcher per line. The value
+// may be quoted. Blank lines and lines starting with '#' are ignored.
+func readLabelFile(file string) ([]labels.Matcher, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read label file '%s': %v", file, err)
+	}
+	defer f.Close()
+
+	var matchers []labels.Matcher
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(text, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.HasSuffix(name, "!") {
+			return nil, fmt.Errorf("invalid label at line %d of '%s', expected 'name=value' or 'name=~regex'", line, file)
+		}
+		t := labels.MatchEqual
+		if strings.HasPrefix(value, "~") {
+			t, value = labels.MatchRegexp, value[1:]
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		m, err := labels.NewMatcher(t, name, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label at line %d of '%s': %v", line, file, err)
+		}
+		matchers = append(matchers, *m)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Unable to read label file '%s': %v", file, err)
+	}
+	return matchers, nil
+}
+
+// globRegex returns the regex matching the glob pattern, where '*' matches any
+// sequence of characters and '?' any character.
+func globRegex(pattern string) string {
+	expr := regexp.QuoteMeta(pattern)
+	expr = strings.ReplaceAll(expr, `\*`, ".*")
+	return strings.ReplaceAll(expr, `\?`, ".")
+}
+
+// alertnameMatcher returns the matcher of --alertname: a regex matcher for a
+// value prefixed with '~', or with '*' or '?' wildcards, and an equality
+// matcher otherwise. Alertmanager anchors the regex, so that a glob matches
+// the whole alertname.
+func alertnameMatcher(value string) (labels.Matcher, error) {
+	t := labels.MatchEqual
+	switch {
+	case strings.HasPrefix(value, "~"):
+		t, value = labels.MatchRegexp, value[1:]
+	case strings.ContainsAny(value, "*?"):
+		t, value = labels.MatchRegexp, globRegex(value)
+	}
+	m, err := labels.NewMatcher(t, "alertname", value)
+	if err != nil {
+		return labels.Matcher{}, fmt.Errorf("invalid --alertname: %v", err)
+	}
+	return *m, nil
+}
+
+// regexAnchors reports whether the regex pattern is explicitly anchored at
+// its start by '^', and at its end by an unescaped '$'.
+func regexAnchors(pattern string) (start, end bool) {
+	start = strings.HasPrefix(pattern, "^")
+	if strings.HasSuffix(pattern, "$") {
+		escapes := 0
+		for i := len(pattern) - 2; i >= 0 && pattern[i] == '\\'; i-- {
+			escapes++
+		}
+		end = escapes%2 == 0
+	}
+	return start, end
+}
+
+// anchorRegexes applies --regex-anchored to the regex matchers. Alertmanager
+// always anchors them, so that the pattern must match the whole label value:
+// an unanchored pattern is logged when anchored is set, and otherwise wrapped
+// to match substrings, like grep, except at the explicit anchors.
+func anchorRegexes(matchers []labels.Matcher, anchored bool) ([]labels.Matcher, error) {
+	out := make([]labels.Matcher, 0, len(matchers))
+	for _, m := range matchers {
+		if m.Type != labels.MatchRegexp && m.Type != labels.MatchNotRegexp {
+			out = append(out, m)
+			continue
+		}
+		start, end := regexAnchors(m.Value)
+		if anchored || (start && end) {
+			if !start && !end {
+				logger.Info("Regex matchers are anchored, the pattern must match the whole label value", "matcher", m.String())
+			}
+			out = append(out, m)
+			continue
+		}
+		value := "(?:" + m.Value + ")"
+		if !start {
+			value = ".*" + value
+		}
+		if !end {
+			value += ".*"
+		}
+		am, err := labels.NewMatcher(m.Type, m.Name, value)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *am)
+	}
+	return out, nil
+}
+
+// parseExcludes returns the 'name!=value' matchers of the 'name=value'
+// exclusions. The value may be quoted.
+func parseExcludes(excludes []string) ([]labels.Matcher, error) {
+	matchers := make([]labels.Matcher, 0, len(excludes))
+	for _, e := range excludes {
+		name, value, ok := strings.Cut(e, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid exclude '%s', expected 'name=value'", e)
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		m, err := labels.NewMatcher(labels.MatchNotEqual, name, value)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, *m)
+	}
+	return matchers, nil
+}
+
+// hasPositiveMatcher reports whether one of the matchers is an equality or a
+// regex match.
+func hasPositiveMatcher(matchers []labels.Matcher) bool {
+	for _, m := range matchers {
+		if m.Type == labels.MatchEqual || m.Type == labels.MatchRegexp {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalMatchers returns the matchers sorted by name without duplicates,
+// and the pairs of matchers which can't both match the same alert, like
+// alertname=foo and alertname=bar.
+func canonicalMatchers(matchers []labels.Matcher) ([]labels.Matcher, []string) {
+	seen := map[string]bool{}
+	out := make([]labels.Matcher, 0, len(matchers))
+	for _, m := range matchers {
+		if key := m.String(); !seen[key] {
+			seen[key] = true
+			out = append(out, m)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].String() < out[j].String()
+	})
+
+	var conflicts []string
+	for i, a := range out {
+		for _, b := range out[i+1:] {
+			if a.Name != b.Name {
+				break
+			}
+			if conflictingMatchers(a, b) || conflictingMatchers(b, a) {
+				conflicts = append(conflicts, fmt.Sprintf("'%s' and '%s'", a.String(), b.String()))
+			}
+		}
+	}
+	return out, conflicts
+}
+
+// conflictingMatchers returns whether the equality matcher a and the matcher
+// b on the same label can't both match.
+func conflictingMatchers(a, b labels.Matcher) bool {
+	if a.Type != labels.MatchEqual {
+		return false
+	}
+	switch b.Type {
+	case labels.MatchEqual:
+		return a.Value != b.Value
+	case labels.MatchNotEqual:
+		return a.Value == b.Value
+	}
+	return false
+}
+
+// anyLabelValues are sample label values, which a matcher matching every
+// alert matches.
+var anyLabelValues = []string{"a", "Z9", "some-value_1.2", "with space"}
+
+// matchesEverything reports whether a positive matcher matches every alert.
+// Every alert has an alertname, but the other labels may be missing, so the
+// matcher must also match the empty value.
+func matchesEverything(m labels.Matcher) bool {
+	if m.Name != "alertname" && !m.Matches("") {
+		return false
+	}
+	for _, v := range anyLabelValues {
+		if !m.Matches(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkSpecificity returns an error naming the matchers making the silence
+// too broad when it has less than min positive matchers not matching every
+// alert.
+func checkSpecificity(matchers []labels.Matcher, min int) error {
+	var (
+		specific int
+		reasons  []string
+	)
+	for _, m := range matchers {
+		switch {
+		case m.Type == labels.MatchNotEqual || m.Type == labels.MatchNotRegexp:
+			reasons = append(reasons, fmt.Sprintf("'%s' is negative", m.String()))
+		case matchesEverything(m):
+			reasons = append(reasons, fmt.Sprintf("'%s' matches every alert", m.String()))
+		default:
+			specific++
+		}
+	}
+	if specific >= min {
+		return nil
+	}
+	msg := fmt.Sprintf("silence is too broad, %d specific matcher(s) for --min-specificity=%d", specific, min)
+	if len(reasons) > 0 {
+		msg += ": " + strings.Join(reasons, ", ")
+	}
+	return fmt.Errorf("%s, use --force to add it anyway", msg)
+}
+
+// validateMatchers checks the parsed matchers the way Alertmanager would, so
+// that an invalid one is reported before any request is sent.
+func validateMatchers(matchers []labels.Matcher) error {
+	for _, m := range matchers {
+		if m.Name == "" {
+			return fmt.Errorf("invalid matcher '%s': empty label name", m.String())
+		}
+		if !utf8.ValidString(m.Value) {
+			return fmt.Errorf("invalid matcher '%s': label value is not valid UTF-8", m.String())
+		}
+		if m.Type == labels.MatchRegexp || m.Type == labels.MatchNotRegexp {
+			if _, err := regexp.Compile("^(?:" + m.Value + ")$"); err != nil {
+				return fmt.Errorf("invalid matcher '%s': %v", m.String(), err)
+			}
+		}
+	}
+	return nil
+}
+
 // TypeMatchers only valid for when you are going to add a silence.
 func TypeMatchers(matchers []labels.Matcher) models.Matchers {
 	typeMatchers := make(models.Matchers, len(matchers))
@@ -32,6 +334,14 @@ func TypeMatchers(matchers []labels.Matcher) models.Matchers {
 }
 
 // TypeMatcher only valid for when you are going to add a silence.
+//
+// The match type is mapped to the API flags as Alertmanager expects them:
+//
+//	type  isEqual  isRegex
+//	=     true     false
+//	!=    false    false
+//	=~    true     true
+//	!~    false    true
 func TypeMatcher(matcher labels.Matcher) *models.Matcher {
 	name := matcher.Name
 	value := matcher.Value
@@ -47,11 +357,170 @@ func TypeMatcher(matcher labels.Matcher) *models.Matcher {
 	return &typeMatcher
 }
 
-// Helper function for adding the ctx with timeout into an action.
+// MatchersEqual reports whether both sets of matchers are the same, whatever
+// their order.
+func MatchersEqual(a, b models.Matchers) bool {
+	return len(a) == len(b) && MatcherKey(a) == MatcherKey(b)
+}
+
+// MatcherKey returns a canonical representation of the matchers, sorted by
+// name, type and value. A missing IsEqual is taken as true, as Alertmanager
+// does.
+func MatcherKey(matchers models.Matchers) string {
+	keys := make([]string, 0, len(matchers))
+	for _, m := range matchers {
+		keys = append(keys, labelsMatcher(*m).String())
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ",")
+}
+
+// MatcherString renders the matcher in the label syntax, like name=value,
+// name=~value, name!=value or name!~value. The value is quoted when it's empty
+// or has characters which would not parse back unquoted.
+func MatcherString(m *models.Matcher) string {
+	lm := labelsMatcher(*m)
+	value := lm.Value
+	if value == "" || strings.ContainsAny(value, " \t\n\r{}!=~,\\\"'`#") || !strconv.CanBackquote(value) {
+		value = strconv.Quote(value)
+	}
+	return lm.Name + lm.Type.String() + value
+}
+
+// labelsMatcher converts an API matcher back into a label matcher, following
+// the mapping of TypeMatcher.
+func labelsMatcher(m models.Matcher) *labels.Matcher {
+	t := labels.MatchEqual
+	// Support for older alertmanager releases, which did not support isEqual.
+	isEqual := m.IsEqual == nil || *m.IsEqual
+	switch {
+	case !*m.IsRegex && !isEqual:
+		t = labels.MatchNotEqual
+	case *m.IsRegex && isEqual:
+		t = labels.MatchRegexp
+	case *m.IsRegex && !isEqual:
+		t = labels.MatchNotRegexp
+	}
+	return &labels.Matcher{Type: t, Name: *m.Name, Value: *m.Value}
+}
+
+// parseMaxDuration parses --max-duration, which must be greater than 0.
+func parseMaxDuration(s string) (model.Duration, error) {
+	md, err := model.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max-duration '%s': %v", s, err)
+	}
+	if md <= 0 {
+		return 0, fmt.Errorf("invalid max-duration '%s': must be greater than 0", s)
+	}
+	return md, nil
+}
+
+// parseTime parses a RFC3339 time, a time without offset in loc, or a time
+// relative to now ('now', 'now+2h', 'now-30m') or to base ('+2h', '-30m').
+func parseTime(s string, now, base time.Time, loc *time.Location) (time.Time, error) {
+	rel := s
+	if strings.HasPrefix(s, "now") {
+		rel, base = strings.TrimPrefix(s, "now"), now
+		if rel == "" {
+			return now, nil
+		}
+	}
+	if len(rel) > 1 && (rel[0] == '+' || rel[0] == '-') {
+		d, err := model.ParseDuration(rel[1:])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative time '%s': %v", s, err)
+		}
+		if rel[0] == '-' {
+			return base.Add(-time.Duration(d)), nil
+		}
+		return base.Add(time.Duration(d)), nil
+	}
+	if rel != s {
+		return time.Time{}, fmt.Errorf("invalid relative time '%s'", s)
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		// An explicit offset is authoritative, otherwise use the location.
+		if naive, nerr := time.ParseInLocation(naiveTimeLayout, s, loc); nerr == nil {
+			return naive, nil
+		}
+	}
+	return t, err
+}
+
+const naiveTimeLayout = "2006-01-02T15:04:05"
+
+// isAbsoluteTime reports whether the time given to parseTime is absolute
+// rather than empty or relative.
+func isAbsoluteTime(s string) bool {
+	return s != "" && !strings.HasPrefix(s, "now") && s[0] != '+' && s[0] != '-'
+}
+
+// stdinFileValue is a file name flag value where '-' stands for stdin. As
+// kingpin parses a lone '-' argument as an empty value, it's mapped back to '-'.
+type stdinFileValue string
+
+func (v *stdinFileValue) Set(s string) error {
+	if s == "" {
+		s = "-"
+	}
+	*v = stdinFileValue(s)
+	return nil
+}
+
+func (v *stdinFileValue) String() string {
+	return string(*v)
+}
+
+// stdinFileVar binds a file name flag accepting '-' for stdin to target.
+func stdinFileVar(f *kingpin.FlagClause, target *string) {
+	f.SetValue((*stdinFileValue)(target))
+}
+
+// Helper function for adding the ctx with the --deadline timeout into an
+// action. The --timeout one is set on the params of each request instead, so
+// that a run over many tenants isn't cut short by a timeout meant for a
+// request.
 func execWithTimeout(fn func(context.Context, *kingpin.ParseContext) error) func(*kingpin.ParseContext) error {
 	return func(x *kingpin.ParseContext) error {
-		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-		defer cancel()
+		ctx := context.Background()
+		if deadline > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, deadline)
+			defer cancel()
+		}
 		return fn(ctx, x)
 	}
 }
+
+// activeSilences returns the active and pending silences of the tenant.
+func activeSilences(ctx context.Context, amclient *client.AlertmanagerAPI, tenant string) (models.GettableSilences, error) {
+	params := silence.NewGetSilencesParams().WithContext(ctx).WithTimeout(timeout)
+	var getOk *silence.GetSilencesOK
+	err := withRetry(ctx, "query silences"+forTenant(tenant), func() (err error) {
+		getOk, err = amclient.Silence.GetSilences(params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	var silences models.GettableSilences
+	for _, s := range getOk.Payload {
+		if *s.Status.State != models.SilenceStatusStateExpired {
+			silences = append(silences, s)
+		}
+	}
+	return silences, nil
+}
+
+// hasSilence reports whether one of the silences has exactly these matchers.
+func hasSilence(silences models.GettableSilences, matchers []labels.Matcher) bool {
+	typeMatchers := TypeMatchers(matchers)
+	for _, s := range silences {
+		if MatchersEqual(s.Matchers, typeMatchers) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,361 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/alertmanager/api/v2/models"
+	"github.com/prometheus/alertmanager/pkg/labels"
+)
+
+// apiMatchers parses the matchers into API matchers.
+func apiMatchers(t *testing.T, matchers ...string) models.Matchers {
+	t.Helper()
+	lms, err := parseMatchers(matchers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return TypeMatchers(lms)
+}
+
+func TestMatchersEqual(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		a, b  []string
+		equal bool
+	}{
+		{name: "same order", a: []string{"alertname=foo", "env=prod"}, b: []string{"alertname=foo", "env=prod"}, equal: true},
+		{name: "reordered", a: []string{"alertname=foo", "env=prod", "team!~db.*"}, b: []string{"team!~db.*", "env=prod", "alertname=foo"}, equal: true},
+		{name: "quoted value", a: []string{`alertname="foo"`}, b: []string{"alertname=foo"}, equal: true},
+		{name: "other value", a: []string{"alertname=foo"}, b: []string{"alertname=bar"}, equal: false},
+		{name: "regex vs literal", a: []string{"alertname=foo"}, b: []string{"alertname=~foo"}, equal: false},
+		{name: "negation", a: []string{"alertname=foo"}, b: []string{"alertname!=foo"}, equal: false},
+		{name: "negative regex", a: []string{"alertname=~foo"}, b: []string{"alertname!~foo"}, equal: false},
+		{name: "subset", a: []string{"alertname=foo"}, b: []string{"alertname=foo", "env=prod"}, equal: false},
+		{name: "duplicated matcher", a: []string{"alertname=foo", "alertname=foo"}, b: []string{"alertname=foo"}, equal: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := apiMatchers(t, tc.a...), apiMatchers(t, tc.b...)
+			if equal := MatchersEqual(a, b); equal != tc.equal {
+				t.Fatalf("expected MatchersEqual %t, got %t (%s vs %s)", tc.equal, equal, MatcherKey(a), MatcherKey(b))
+			}
+			if equal := MatchersEqual(b, a); equal != tc.equal {
+				t.Fatalf("expected MatchersEqual to be symmetric")
+			}
+		})
+	}
+}
+
+func TestMatcherKey(t *testing.T) {
+	key := MatcherKey(apiMatchers(t, "team!~db.*", "env=prod", "alertname=~Disk.*", "instance!=host-1"))
+	if expected := `alertname=~"Disk.*",env="prod",instance!="host-1",team!~"db.*"`; key != expected {
+		t.Fatalf("expected %s, got %s", expected, key)
+	}
+
+	// The matchers of the older Alertmanager releases have no isEqual, which
+	// is taken as true.
+	var (
+		name, value = "alertname", "foo"
+		isRegex     = false
+	)
+	legacy := models.Matchers{{Name: &name, Value: &value, IsRegex: &isRegex}}
+	if !MatchersEqual(legacy, apiMatchers(t, "alertname=foo")) {
+		t.Fatalf("expected a matcher without isEqual to be an equality, got %s", MatcherKey(legacy))
+	}
+}
+
+func TestMatcherString(t *testing.T) {
+	for _, tc := range []struct {
+		matcher  string
+		expected string
+	}{
+		{matcher: "alertname=foo", expected: "alertname=foo"},
+		{matcher: "alertname!=foo", expected: "alertname!=foo"},
+		{matcher: "alertname=~Disk.*", expected: "alertname=~Disk.*"},
+		{matcher: "alertname!~Disk.*", expected: "alertname!~Disk.*"},
+		{matcher: `alertname=""`, expected: `alertname=""`},
+		{matcher: `summary="disk full"`, expected: `summary="disk full"`},
+		{matcher: `job=~"node|blackbox"`, expected: `job=~node|blackbox`},
+		{matcher: `path="a,b"`, expected: `path="a,b"`},
+		{matcher: `expr="a=b"`, expected: `expr="a=b"`},
+		{matcher: `query="{job!~\"x\"}"`, expected: `query="{job!~\"x\"}"`},
+		{matcher: `text="line\nbreak"`, expected: `text="line\nbreak"`},
+		{matcher: `path="C:\\dir"`, expected: `path="C:\\dir"`},
+		{matcher: `quote="it's"`, expected: `quote="it's"`},
+		{matcher: `hash="#1"`, expected: `hash="#1"`},
+		{matcher: `label="café"`, expected: `label=café`},
+	} {
+		t.Run(tc.matcher, func(t *testing.T) {
+			m := apiMatchers(t, tc.matcher)[0]
+			s := MatcherString(m)
+			if s != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, s)
+			}
+			// The rendered matcher parses back to the same matcher.
+			parsed := apiMatchers(t, s)
+			if !MatchersEqual(parsed, models.Matchers{m}) {
+				t.Fatalf("expected %s to parse back to %s, got %s", s, MatcherKey(models.Matchers{m}), MatcherKey(parsed))
+			}
+			if again := MatcherString(parsed[0]); again != s {
+				t.Fatalf("expected a stable rendering, got %s then %s", s, again)
+			}
+		})
+	}
+}
+
+func TestTypeMatcher(t *testing.T) {
+	for _, tc := range []struct {
+		name             string
+		matchType        labels.MatchType
+		isEqual, isRegex bool
+	}{
+		{name: "MatchEqual", matchType: labels.MatchEqual, isEqual: true, isRegex: false},
+		{name: "MatchNotEqual", matchType: labels.MatchNotEqual, isEqual: false, isRegex: false},
+		{name: "MatchRegexp", matchType: labels.MatchRegexp, isEqual: true, isRegex: true},
+		{name: "MatchNotRegexp", matchType: labels.MatchNotRegexp, isEqual: false, isRegex: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lm, err := labels.NewMatcher(tc.matchType, "alertname", "foo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := TypeMatcher(*lm)
+			if *m.Name != "alertname" || *m.Value != "foo" {
+				t.Fatalf("unexpected matcher %s=%s", *m.Name, *m.Value)
+			}
+			if m.IsEqual == nil || m.IsRegex == nil {
+				t.Fatal("expected isEqual and isRegex to be set")
+			}
+			if *m.IsEqual != tc.isEqual || *m.IsRegex != tc.isRegex {
+				t.Fatalf("expected isEqual=%t isRegex=%t, got isEqual=%t isRegex=%t", tc.isEqual, tc.isRegex, *m.IsEqual, *m.IsRegex)
+			}
+			// labelsMatcher is the inverse of TypeMatcher.
+			if back := labelsMatcher(*m); back.Type != tc.matchType || back.Name != lm.Name || back.Value != lm.Value {
+				t.Fatalf("expected %s back, got %s", lm, back)
+			}
+		})
+	}
+}
+
+func TestReadLabelFile(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		content  string
+		matchers string
+		err      string
+	}{
+		{
+			name:     "equality and regex",
+			content:  "env=prod\nalertname=~Disk.*\n",
+			matchers: `alertname=~"Disk.*",env="prod"`,
+		},
+		{
+			name:     "comments, blanks and spaces",
+			content:  "# labels of the deploy\n\n  env = prod  \r\n\t\ninstance=~ \"host-[0-9]+\"\n",
+			matchers: `env="prod",instance=~"host-[0-9]+"`,
+		},
+		{
+			name:     "quoted value",
+			content:  `summary="disk full"` + "\n" + `team='db'` + "\n",
+			matchers: `summary="disk full",team="'db'"`,
+		},
+		{
+			name:     "equal sign in the value",
+			content:  "expr=a=b\n",
+			matchers: `expr="a=b"`,
+		},
+		{name: "no equal sign", content: "env=prod\nalertname\n", err: "invalid label at line 2"},
+		{name: "no name", content: "=prod\n", err: "invalid label at line 1"},
+		{name: "negative matcher", content: "env!=prod\n", err: "invalid label at line 1"},
+		{name: "invalid regex", content: "# comment\nalertname=~(\n", err: "invalid label at line 2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			matchers, err := readLabelFile(writeTenantFile(t, "labels.properties", tc.content))
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if key := MatcherKey(TypeMatchers(matchers)); key != tc.matchers {
+				t.Fatalf("expected %s, got %s", tc.matchers, key)
+			}
+		})
+	}
+
+	if _, err := readLabelFile(filepath.Join(t.TempDir(), "missing.properties")); err == nil || !strings.Contains(err.Error(), "Unable to read label file") {
+		t.Fatalf("expected a read error, got %v", err)
+	}
+}
+
+func TestCanonicalMatchers(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		matchers  []string
+		canonical string
+		conflicts []string
+	}{
+		{
+			name:      "duplicates",
+			matchers:  []string{"alertname=foo", "env=prod", "alertname=foo", `env="prod"`},
+			canonical: `alertname="foo", env="prod"`,
+		},
+		{
+			name:      "sorted by name",
+			matchers:  []string{"team=db", "alertname=foo", "env!~dev|test", "env=~prod.*"},
+			canonical: `alertname="foo", env!~"dev|test", env=~"prod.*", team="db"`,
+		},
+		{
+			name:      "regex and literal kept apart",
+			matchers:  []string{"alertname=~foo", "alertname=foo"},
+			canonical: `alertname="foo", alertname=~"foo"`,
+		},
+		{
+			name:      "conflicting equalities",
+			matchers:  []string{"alertname=foo", "env=prod", "alertname=bar"},
+			canonical: `alertname="bar", alertname="foo", env="prod"`,
+			conflicts: []string{`'alertname="bar"' and 'alertname="foo"'`},
+		},
+		{
+			name:      "equality and its negation",
+			matchers:  []string{"env!=prod", "env=prod"},
+			canonical: `env!="prod", env="prod"`,
+			conflicts: []string{`'env!="prod"' and 'env="prod"'`},
+		},
+		{
+			name:      "equality and a distinct negation",
+			matchers:  []string{"env!=dev", "env=prod"},
+			canonical: `env!="dev", env="prod"`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			lms, err := parseMatchers(tc.matchers)
+			if err != nil {
+				t.Fatal(err)
+			}
+			canonical, conflicts := canonicalMatchers(lms)
+			var ms []string
+			for _, m := range canonical {
+				ms = append(ms, m.String())
+			}
+			if s := strings.Join(ms, ", "); s != tc.canonical {
+				t.Fatalf("expected %s, got %s", tc.canonical, s)
+			}
+			if !reflect.DeepEqual(conflicts, tc.conflicts) {
+				t.Fatalf("expected the conflicts %q, got %q", tc.conflicts, conflicts)
+			}
+		})
+	}
+}
+
+func TestAlertnameMatcher(t *testing.T) {
+	for _, tc := range []struct {
+		value   string
+		matcher string
+		err     string
+	}{
+		{value: "DiskFull", matcher: `alertname="DiskFull"`},
+		{value: "Disk.Full", matcher: `alertname="Disk.Full"`},
+		{value: "Disk*", matcher: `alertname=~"Disk.*"`},
+		{value: "*Full", matcher: `alertname=~".*Full"`},
+		{value: "Disk?ull", matcher: `alertname=~"Disk.ull"`},
+		{value: "Disk.*", matcher: `alertname=~"Disk\\..*"`},
+		{value: "~Disk(Full|Pressure)", matcher: `alertname=~"Disk(Full|Pressure)"`},
+		{value: "~Disk*", matcher: `alertname=~"Disk*"`},
+		{value: "~Disk(", err: "invalid --alertname"},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			m, err := alertnameMatcher(tc.value)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if m.String() != tc.matcher {
+				t.Fatalf("expected %s, got %s", tc.matcher, m.String())
+			}
+		})
+	}
+}
+
+func TestRegexAnchors(t *testing.T) {
+	for _, tc := range []struct {
+		pattern    string
+		start, end bool
+	}{
+		{pattern: "foo"},
+		{pattern: "^foo", start: true},
+		{pattern: "foo$", end: true},
+		{pattern: "^foo$", start: true, end: true},
+		{pattern: `foo\$`},
+		{pattern: `foo\\$`, end: true},
+		{pattern: `^foo\\\$`, start: true},
+		{pattern: "$", end: true},
+	} {
+		t.Run(tc.pattern, func(t *testing.T) {
+			if start, end := regexAnchors(tc.pattern); start != tc.start || end != tc.end {
+				t.Fatalf("expected start %t and end %t, got %t and %t", tc.start, tc.end, start, end)
+			}
+		})
+	}
+}
+
+func TestAnchorRegexes(t *testing.T) {
+	for _, tc := range []struct {
+		matcher  string
+		anchored bool
+		expected string
+		info     bool
+	}{
+		{matcher: "alertname=foo", expected: `alertname="foo"`},
+		{matcher: "alertname=~Disk.*", anchored: true, expected: `alertname=~"Disk.*"`, info: true},
+		{matcher: "alertname=~^Disk.*$", anchored: true, expected: `alertname=~"^Disk.*$"`},
+		{matcher: "alertname=~Disk", expected: `alertname=~".*(?:Disk).*"`},
+		{matcher: "alertname!~Disk", expected: `alertname!~".*(?:Disk).*"`},
+		{matcher: "alertname=~^Disk", expected: `alertname=~"(?:^Disk).*"`},
+		{matcher: "alertname=~Full$", expected: `alertname=~".*(?:Full$)"`},
+		{matcher: "alertname=~^DiskFull$", expected: `alertname=~"^DiskFull$"`},
+		{matcher: "alertname=~Disk|Host", expected: `alertname=~".*(?:Disk|Host).*"`},
+	} {
+		t.Run(fmt.Sprintf("%s anchored %t", tc.matcher, tc.anchored), func(t *testing.T) {
+			lms, err := parseMatchers([]string{tc.matcher})
+			if err != nil {
+				t.Fatal(err)
+			}
+			logs := captureLogs(t)
+			out, err := anchorRegexes(lms, tc.anchored)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if s := out[0].String(); s != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, s)
+			}
+			if info := strings.Contains(logs.String(), "Regex matchers are anchored"); info != tc.info {
+				t.Fatalf("expected the anchoring message %t, got %q", tc.info, logs)
+			}
+		})
+	}
+}
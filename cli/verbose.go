@@ -0,0 +1,152 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	promconfig "github.com/prometheus/common/config"
+)
+
+const redacted = "<redacted>"
+
+var (
+	verbose            bool
+	verboseShowSecrets bool
+
+	// verboseMtx keeps the requests of the tenants processed in parallel
+	// from being interleaved.
+	verboseMtx sync.Mutex
+
+	// secretHeaders are the headers set on the requests, not by the HTTP
+	// config, to redact.
+	secretHeaders sync.Map
+)
+
+// redactHeader makes --verbose redact the header.
+func redactHeader(name string) {
+	secretHeaders.Store(http.CanonicalHeaderKey(name), true)
+}
+
+// verboseRoundTripper writes the requests to stderr before sending them.
+//
+// The headers and the authorization of the HTTP config are added by inner
+// round trippers, so they are rebuilt from the config. The authorization and
+// the secret headers, the tenant one included, are redacted unless
+// --verbose.show-secrets is set.
+type verboseRoundTripper struct {
+	config promconfig.HTTPClientConfig
+	rt     http.RoundTripper
+}
+
+func (v *verboseRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	header, secret := v.header(req)
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "> %s %s\n", req.Method, req.URL)
+	for _, name := range names {
+		for _, value := range header[name] {
+			if secret[http.CanonicalHeaderKey(name)] && !verboseShowSecrets {
+				value = redacted
+			}
+			fmt.Fprintf(&b, "> %s: %s\n", name, value)
+		}
+	}
+	if len(body) > 0 {
+		fmt.Fprintf(&b, ">\n%s\n", bytes.TrimSpace(body))
+	}
+
+	verboseMtx.Lock()
+	fmt.Fprint(os.Stderr, b.String())
+	verboseMtx.Unlock()
+
+	return v.rt.RoundTrip(req)
+}
+
+// header returns the headers which will be sent with the request, and the
+// ones to redact.
+func (v *verboseRoundTripper) header(req *http.Request) (http.Header, map[string]bool) {
+	header := req.Header.Clone()
+	secret := map[string]bool{"Authorization": true, "Proxy-Authorization": true, "Cookie": true}
+	secretHeaders.Range(func(name, _ interface{}) bool {
+		secret[name.(string)] = true
+		return true
+	})
+
+	if v.config.HTTPHeaders != nil {
+		for name, h := range v.config.HTTPHeaders.Headers {
+			for _, value := range h.Values {
+				header.Add(name, value)
+			}
+			for _, value := range h.Secrets {
+				header.Add(name, string(value))
+			}
+			for _, file := range h.Files {
+				value, err := os.ReadFile(file)
+				if err != nil {
+					value = []byte(fmt.Sprintf("<unable to read '%s'>", file))
+				}
+				header.Add(name, strings.TrimSpace(string(value)))
+			}
+			if len(h.Secrets) > 0 || len(h.Files) > 0 {
+				secret[http.CanonicalHeaderKey(name)] = true
+			}
+		}
+	}
+
+	switch {
+	case v.config.Authorization != nil:
+		credentials := string(v.config.Authorization.Credentials)
+		if file := v.config.Authorization.CredentialsFile; file != "" {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				b = []byte(fmt.Sprintf("<unable to read '%s'>", file))
+			}
+			credentials = strings.TrimSpace(string(b))
+		}
+		authType := v.config.Authorization.Type
+		if authType == "" {
+			authType = "Bearer"
+		}
+		header.Set("Authorization", authType+" "+credentials)
+	case v.config.BasicAuth != nil:
+		header.Set("Authorization", "Basic "+redacted)
+	case v.config.OAuth2 != nil:
+		header.Set("Authorization", "Bearer <oauth2 token>")
+	}
+	return header, secret
+}
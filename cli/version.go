@@ -0,0 +1,63 @@
+// Copyright 2018 Prometheus Team
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/prometheus/common/version"
+)
+
+const versionHelp = `Show the version of atm
+
+  The version, commit, branch, build date and Go version are printed as
+  with --version, or as a JSON or YAML object with --output=json and
+  --output=yaml, so that scripts can check the atm version before relying
+  on a feature:
+
+  atm version -o json | jq -r .version
+`
+
+// configureVersionCmd represents the version command.
+func configureVersionCmd(app *kingpin.Application) {
+	app.Command("version", versionHelp).Action(printVersion)
+}
+
+// versionJSON is the JSON representation of the version of atm.
+type versionJSON struct {
+	Version   string `json:"version"`
+	Revision  string `json:"revision"`
+	Branch    string `json:"branch"`
+	BuildUser string `json:"buildUser"`
+	BuildDate string `json:"buildDate"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+func printVersion(_ *kingpin.ParseContext) error {
+	if structuredOutput() {
+		return printStructured(versionJSON{
+			Version:   version.Version,
+			Revision:  version.GetRevision(),
+			Branch:    version.Branch,
+			BuildUser: version.BuildUser,
+			BuildDate: version.BuildDate,
+			GoVersion: version.GoVersion,
+			Platform:  version.GoOS + "/" + version.GoArch,
+		})
+	}
+	fmt.Fprintln(stdout, version.Print("atm"))
+	return nil
+}